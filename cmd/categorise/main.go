@@ -2,6 +2,7 @@ package main
 
 import (
 	"flag"
+	"log"
 	"reorg/pkg/adapters"
 	"reorg/pkg/command"
 	"reorg/pkg/domain"
@@ -10,18 +11,37 @@ import (
 func main() {
 	osfs := &adapters.OsFileSystem{}
 
+	filesService := domain.NewFileSystemService(osfs)
+
+	i, fd, stopwords := parseFlags()
+
+	var words map[string][]string
+	if stopwords != "" {
+		b, err := filesService.ReadFile(stopwords)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if words, err = domain.ParseStopwords(b); err != nil {
+			log.Fatal(err)
+		}
+	}
+
 	command.Run(&command.Categorise{
-		InPath: parseFlag(),
-		Files:  domain.NewFileSystemService(osfs),
-		Notes:  domain.NewNoteService(osfs),
+		InPath:          i,
+		FolderAsDefault: fd,
+		Files:           filesService,
+		Notes:           domain.NewNoteService(osfs, domain.WithStopwords(words)),
 	})
 }
 
-// parseFlag parses the required flag
-func parseFlag() string {
+// parseFlags parses the required flags
+func parseFlags() (string, bool, string) {
 	i := flag.String("i", "", "relative path to directory of cleaned files")
+	fd := flag.Bool("fd", false, "default each note's category to its source folder")
+	stopwords := flag.String("stopwords", "", "relative path to a json file of stopwords for each language, excluded from keywords")
 
 	flag.Parse()
 
-	return *i
+	return *i, *fd, *stopwords
 }
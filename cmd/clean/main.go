@@ -1,47 +1,248 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"log"
+	"path/filepath"
 	"reorg/pkg/adapters"
 	"reorg/pkg/command"
 	"reorg/pkg/domain"
+	"strings"
+	"time"
 )
 
+// flags represents the parsed command-line flags
+type flags struct {
+	inPath   string
+	outPath  string
+	from     string
+	include  string
+	exclude  string
+	dateBy   string
+	timezone string
+	layouts  string
+	labels   string
+	rules    string
+	content  string
+	encoding string
+	region   string
+	slugs    string
+	paths    stringList
+	tolerant bool
+	portable bool
+	workers  int
+	json     bool
+	raw      bool
+	txt      bool
+}
+
 func main() {
-	osfs := &adapters.OsFileSystem{}
-	filesService := domain.NewFileSystemService(osfs)
+	f := parseFlags()
+
+	var fs domain.FileSystem = &adapters.OsFileSystem{}
+
+	// read the export directly from an archive if provided
+	archive, err := adapters.OpenArchive(fs, f.inPath)
+	switch {
+	case err == nil:
+		defer archive.Close()
+		fs = archive
+	case !errors.Is(err, adapters.ErrNotArchive) && !fs.IsNotExist(err):
+		log.Fatal(err)
+	}
 
-	i, o, j, t := parseFlags()
+	filesService := domain.NewFileSystemService(fs)
+
+	dateBy, err := domain.ParseTimestampKind(f.dateBy)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	tp, err := parseTimestampParser(f.timezone, f.layouts)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	mode, err := domain.ParseContentMode(f.content)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var profiles []domain.HeaderProfile
+	if f.labels != "" {
+		b, err := filesService.ReadFile(f.labels)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if profiles, err = domain.ParseHeaderProfiles(b); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	var rules *domain.RuleSet
+	if f.rules != "" {
+		b, err := filesService.ReadFile(f.rules)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		isJSON := strings.EqualFold(filepath.Ext(f.rules), ".json")
+		if rules, err = domain.ParseRuleSet(b, !isJSON); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	paths, err := domain.ParsePathTemplates(f.paths)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var encoding string
+	if f.encoding != "" {
+		if encoding, err = domain.ParseEncoding(f.encoding); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	region, err := domain.ParsePhoneRegion(f.region)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	slugs, err := domain.ParseSlugMode(f.slugs)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	notesService := domain.NewNoteService(
+		fs,
+		domain.WithTimestampParser(tp),
+		domain.WithContentMode(mode),
+		domain.WithWorkers(f.workers),
+		domain.WithEncoding(encoding),
+		domain.WithHeaderProfiles(profiles...),
+		domain.WithRuleSet(rules),
+		domain.WithPhoneRegion(region),
+		domain.WithFilenamePolicy(domain.FilenamePolicy{Mode: slugs, Portable: f.portable}),
+	)
+
+	var im domain.Importer
+
+	switch f.from {
+	case "gnotes":
+		im = &adapters.GNotesImporter{
+			Files:   filesService,
+			Notes:   notesService,
+			Include: parseList(f.include),
+			Exclude: parseList(f.exclude),
+		}
+	case "gnotesdb":
+		im = &adapters.GNotesDBImporter{Files: filesService, Notes: notesService}
+	case "keep":
+		im = &adapters.KeepImporter{Files: filesService, Notes: notesService}
+	case "enex":
+		im = &adapters.ENEXImporter{Files: filesService, Notes: notesService}
+	case "simplenote":
+		im = &adapters.SimplenoteImporter{Files: filesService, Notes: notesService}
+	default:
+		log.Fatalf("invalid export format: %s", f.from)
+	}
 
 	var wr domain.NoteWriter
 
 	switch {
-	case j == t:
+	case f.json == f.txt:
 		log.Fatal("must specify output either json or txt")
-	case t:
+	case f.raw && !f.json:
+		log.Fatal("raw html can only be embedded in json output")
+	case f.txt:
 		wr = &adapters.TxtNoteWriter{Files: filesService}
-	case j:
-		wr = &adapters.JSONNoteWriter{Files: filesService}
+	case f.json:
+		wr = &adapters.JSONNoteWriter{Files: filesService, EmbedRaw: f.raw}
 	}
 
 	command.Run(&command.Clean{
-		InPath:  i,
-		OutPath: o,
-		Writer:  wr,
-		Files:   filesService,
-		Notes:   domain.NewNoteService(osfs),
+		InPath:   f.inPath,
+		OutPath:  f.outPath,
+		Importer: im,
+		DateBy:   dateBy,
+		Tolerant: f.tolerant,
+		Workers:  f.workers,
+		Rules:    rules,
+		Paths:    paths,
+		Flat:     f.json,
+		Writer:   wr,
+		Files:    filesService,
+		Notes:    notesService,
 	})
 }
 
 // parseFlags parses the required flags
-func parseFlags() (string, string, bool, bool) {
-	i := flag.String("i", "", "relative path to gnotes export directory")
-	o := flag.String("o", "", "relative path to output directory for cleaned notes")
-	j := flag.Bool("json", false, "output cleaned notes as json files")
-	t := flag.Bool("txt", false, "output cleaned notes as txt files")
+func parseFlags() flags {
+	var f flags
+
+	flag.StringVar(&f.inPath, "i", "", "relative path to export directory, file or archive (zip, tar or tar.gz)")
+	flag.StringVar(&f.outPath, "o", "", "relative path to output directory for cleaned notes")
+	flag.StringVar(&f.from, "from", "gnotes", "format of the export (gnotes, gnotesdb, keep, enex or simplenote)")
+	flag.StringVar(&f.include, "include", "", "comma-separated names of gnotes export folders to clean (defaults to all)")
+	flag.StringVar(&f.exclude, "exclude", "", "comma-separated names of gnotes export folders to skip")
+	flag.StringVar(&f.dateBy, "date", string(domain.CreatedTimestamp), "timestamp to date notes by (created or modified)")
+	flag.StringVar(&f.timezone, "tz", domain.DefaultLocation, "timezone that raw note timestamps are parsed in")
+	flag.StringVar(&f.layouts, "layouts", strings.Join(domain.DefaultTimestampLayouts, ","), "comma-separated candidate layouts for raw note timestamps")
+	flag.StringVar(&f.labels, "labels", "", "relative path to a json file of custom header label profiles")
+	flag.StringVar(&f.rules, "rules", "", "relative path to a yaml or json file of ordered find/replace and strip rules")
+	flag.StringVar(&f.content, "content", string(domain.PlainTextContent), "format to convert note content to (text or markdown)")
+	flag.StringVar(&f.encoding, "encoding", "", "encoding of raw note files, such as gbk, shift_jis or windows-1252 (detected per file by default)")
+	flag.StringVar(&f.slugs, "slugs", string(domain.ASCIISlugs), "how note titles are converted to filenames (ascii, unicode or translit)")
+	flag.BoolVar(&f.portable, "portable", false, "avoid filenames that cannot be written on windows, such as reserved device names")
+	flag.StringVar(&f.region, "region", domain.DefaultPhoneRegion, "region code that phone numbers without an international prefix are parsed in")
+	flag.Var(&f.paths, "path", "template of each note's output path, excluding its extension (may be repeated, the first that gives every note a unique path is used)")
+	flag.BoolVar(&f.tolerant, "tolerant", false, "quarantine notes that fail to parse and report failures, rather than aborting")
+	flag.IntVar(&f.workers, "workers", domain.DefaultWorkers, "number of notes to parse concurrently")
+	flag.BoolVar(&f.json, "json", false, "output cleaned notes as json files")
+	flag.BoolVar(&f.raw, "raw", false, "embed the raw html source of each note in json files, so that they can be re-cleaned")
+	flag.BoolVar(&f.txt, "txt", false, "output cleaned notes as txt files")
 
 	flag.Parse()
 
-	return *i, *o, *j, *t
+	return f
+}
+
+// parseTimestampParser parses a timestamp parser from the provided timezone and comma-separated layouts
+func parseTimestampParser(timezone, layouts string) (*domain.TimestampParser, error) {
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	return domain.NewTimestampParser(loc, parseList(layouts))
+}
+
+// parseList parses a list of values from the provided comma-separated string
+func parseList(s string) []string {
+	var values []string
+
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+
+	return values
+}
+
+// stringList represents a flag that can be provided more than once, retaining each value in order
+type stringList []string
+
+// String implements flag.Value
+func (s *stringList) String() string {
+	return strings.Join(*s, ", ")
+}
+
+// Set implements flag.Value
+func (s *stringList) Set(v string) error {
+	*s = append(*s, v)
+	return nil
 }
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"reorg/pkg/adapters"
+	"reorg/pkg/command"
+	"reorg/pkg/domain"
+	"strings"
+	"time"
+)
+
+// flags represents the parsed command-line flags
+type flags struct {
+	inPath   string
+	dateBy   string
+	timezone string
+	layouts  string
+	labels   string
+	encoding string
+	slugs    string
+	workers  int
+	json     bool
+	portable bool
+}
+
+func main() {
+	f := parseFlags()
+
+	var fs domain.FileSystem = &adapters.OsFileSystem{}
+
+	// read the export directly from an archive if provided
+	archive, err := adapters.OpenArchive(fs, f.inPath)
+	switch {
+	case err == nil:
+		defer archive.Close()
+		fs = archive
+	case !errors.Is(err, adapters.ErrNotArchive) && !fs.IsNotExist(err):
+		fail(err)
+	}
+
+	filesService := domain.NewFileSystemService(fs)
+
+	dateBy, err := domain.ParseTimestampKind(f.dateBy)
+	if err != nil {
+		fail(err)
+	}
+
+	tp, err := parseTimestampParser(f.timezone, f.layouts)
+	if err != nil {
+		fail(err)
+	}
+
+	var profiles []domain.HeaderProfile
+	if f.labels != "" {
+		b, err := filesService.ReadFile(f.labels)
+		if err != nil {
+			fail(err)
+		}
+
+		if profiles, err = domain.ParseHeaderProfiles(b); err != nil {
+			fail(err)
+		}
+	}
+
+	var encoding string
+	if f.encoding != "" {
+		if encoding, err = domain.ParseEncoding(f.encoding); err != nil {
+			fail(err)
+		}
+	}
+
+	slugs, err := domain.ParseSlugMode(f.slugs)
+	if err != nil {
+		fail(err)
+	}
+
+	notesService := domain.NewNoteService(
+		fs,
+		domain.WithTimestampParser(tp),
+		domain.WithWorkers(f.workers),
+		domain.WithEncoding(encoding),
+		domain.WithHeaderProfiles(profiles...),
+		domain.WithFilenamePolicy(domain.FilenamePolicy{Mode: slugs, Portable: f.portable}),
+	)
+
+	command.Run(&command.Lint{
+		InPath:   f.inPath,
+		Importer: &adapters.GNotesImporter{Files: filesService, Notes: notesService},
+		JSON:     f.json,
+		Out:      os.Stdout,
+		DateBy:   dateBy,
+		Workers:  f.workers,
+		Files:    filesService,
+	})
+}
+
+// parseFlags parses the required flags
+func parseFlags() flags {
+	var f flags
+
+	flag.StringVar(&f.inPath, "i", "", "relative path to gnotes export directory or archive (zip, tar or tar.gz)")
+	flag.StringVar(&f.dateBy, "date", string(domain.CreatedTimestamp), "timestamp that notes would be dated by (created or modified)")
+	flag.StringVar(&f.timezone, "tz", domain.DefaultLocation, "timezone that raw note timestamps are parsed in")
+	flag.StringVar(&f.layouts, "layouts", strings.Join(domain.DefaultTimestampLayouts, ","), "comma-separated candidate layouts for raw note timestamps")
+	flag.StringVar(&f.labels, "labels", "", "relative path to a json file of custom header label profiles")
+	flag.StringVar(&f.encoding, "encoding", "", "encoding of raw note files, such as gbk, shift_jis or windows-1252 (detected per file by default)")
+	flag.StringVar(&f.slugs, "slugs", string(domain.ASCIISlugs), "how note titles are converted to filenames (ascii, unicode or translit)")
+	flag.BoolVar(&f.portable, "portable", false, "avoid filenames that cannot be written on windows, such as reserved device names")
+	flag.IntVar(&f.workers, "workers", domain.DefaultWorkers, "number of notes to parse concurrently")
+	flag.BoolVar(&f.json, "json", false, "output the report as json rather than a human summary")
+
+	flag.Parse()
+
+	return f
+}
+
+// parseTimestampParser parses a timestamp parser from the provided timezone and comma-separated layouts
+func parseTimestampParser(timezone, layouts string) (*domain.TimestampParser, error) {
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	return domain.NewTimestampParser(loc, parseList(layouts))
+}
+
+// parseList parses a list of values from the provided comma-separated string
+func parseList(s string) []string {
+	var values []string
+
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+
+	return values
+}
+
+// fail exits with the provided error, as an export that cannot be linted
+func fail(err error) {
+	command.Exit(command.LintSetupFailure(err))
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"path/filepath"
+	"reorg/pkg/adapters"
+	"reorg/pkg/command"
+	"reorg/pkg/domain"
+	"strings"
+)
+
+func main() {
+	osfs := &adapters.OsFileSystem{}
+	filesService := domain.NewFileSystemService(osfs)
+
+	i, labels, rulesPath, phoneRegion := parseFlags()
+
+	var profiles []domain.HeaderProfile
+	if labels != "" {
+		b, err := filesService.ReadFile(labels)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if profiles, err = domain.ParseHeaderProfiles(b); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	var rules *domain.RuleSet
+	if rulesPath != "" {
+		b, err := filesService.ReadFile(rulesPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		isJSON := strings.EqualFold(filepath.Ext(rulesPath), ".json")
+		if rules, err = domain.ParseRuleSet(b, !isJSON); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	region, err := domain.ParsePhoneRegion(phoneRegion)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	command.Run(&command.Reclean{
+		InPath: i,
+		Rules:  rules,
+		Files:  filesService,
+		Notes: domain.NewNoteService(
+			osfs,
+			domain.WithHeaderProfiles(profiles...),
+			domain.WithRuleSet(rules),
+			domain.WithPhoneRegion(region),
+		),
+	})
+}
+
+// parseFlags parses the required flags
+func parseFlags() (string, string, string, string) {
+	i := flag.String("i", "", "relative path to directory of cleaned json files")
+	labels := flag.String("labels", "", "relative path to a json file of custom header label profiles")
+	rules := flag.String("rules", "", "relative path to a yaml or json file of ordered find/replace and strip rules")
+	region := flag.String("region", domain.DefaultPhoneRegion, "region code that phone numbers without an international prefix are parsed in")
+
+	flag.Parse()
+
+	return *i, *labels, *rules, *region
+}
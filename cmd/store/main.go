@@ -6,13 +6,24 @@ import (
 	"reorg/pkg/adapters"
 	"reorg/pkg/command"
 	"reorg/pkg/domain"
+	"strings"
 )
 
 func main() {
 	osfs := &adapters.OsFileSystem{}
 	filesService := domain.NewFileSystemService(osfs)
 
-	i, f, g := parseFlags()
+	i, f, g, patterns, archived := parseFlags()
+
+	paths, err := domain.ParsePathTemplates(patterns)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	archiveMode, err := domain.ParseArchiveMode(archived)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	var wr domain.NoteWriter
 
@@ -26,20 +37,40 @@ func main() {
 	}
 
 	command.Run(&command.Store{
-		InPath: i,
-		Writer: wr,
-		Files:  filesService,
-		Notes:  domain.NewNoteService(osfs),
+		InPath:   i,
+		Paths:    paths,
+		Archived: archiveMode,
+		Writer:   wr,
+		Files:    filesService,
+		Notes:    domain.NewNoteService(osfs),
 	})
 }
 
 // parseFlags parses the required flags
-func parseFlags() (string, bool, bool) {
+func parseFlags() (string, bool, bool, []string, string) {
 	i := flag.String("i", "", "relative path to directory of cleaned files and manifest")
 	f := flag.Bool("f", false, "destination file system <input_path>/categorised")
 	g := flag.Bool("g", false, "destination google storage")
+	a := flag.String("archived", string(domain.SeparateArchived), "how archived notes are stored (separate, skip or include)")
+
+	var paths stringList
+	flag.Var(&paths, "path", "template of each note's path within the destination, excluding its extension (may be repeated, the first that gives every note a unique path is used)")
 
 	flag.Parse()
 
-	return *i, *f, *g
+	return *i, *f, *g, paths, *a
+}
+
+// stringList represents a flag that can be provided more than once, retaining each value in order
+type stringList []string
+
+// String implements flag.Value
+func (s *stringList) String() string {
+	return strings.Join(*s, ", ")
+}
+
+// Set implements flag.Value
+func (s *stringList) Set(v string) error {
+	*s = append(*s, v)
+	return nil
 }
@@ -0,0 +1,135 @@
+package adapters
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"strings"
+)
+
+// androidBackupMagic defines the first line of an android backup file
+const androidBackupMagic = "ANDROID BACKUP"
+
+// gnotesPackageName defines the substring of the android package name of gnotes
+const gnotesPackageName = "gnotes"
+
+// sqliteIndexSuffix defines the suffix of the shared memory index of a write-ahead log, which is rebuilt once opened
+const sqliteIndexSuffix = "-shm"
+
+// sqliteChangeSuffixes defines the suffixes of the files alongside an sqlite database that hold changes which have
+// not yet been written to the database file itself, being its write-ahead log and rollback journal
+var sqliteChangeSuffixes = []string{"-wal", "-journal"}
+
+// readAndroidBackup returns the contents of each gnotes database within the provided android backup, keyed by path,
+// along with the contents of its write-ahead log or rollback journal, if any
+//
+// Backups that are compressed are supported, whereas backups that are encrypted are not.
+func readAndroidBackup(b []byte) (map[string]gnotesDB, error) {
+	r := bufio.NewReader(bytes.NewReader(b))
+
+	// header comprises magic, version, compression flag and encryption algorithm, each on its own line
+	var header [4]string
+	for idx := range header {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, fmt.Errorf("cannot read android backup header: %w", err)
+		}
+		header[idx] = strings.TrimSpace(line)
+	}
+
+	if header[0] != androidBackupMagic {
+		return nil, errors.New("not an android backup")
+	}
+
+	if header[3] != "none" {
+		return nil, fmt.Errorf("encrypted android backups are not supported (encryption: %s), create the backup without a password", header[3])
+	}
+
+	var body io.Reader = r
+	if header[2] == "1" {
+		zr, err := zlib.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("cannot decompress android backup: %w", err)
+		}
+		defer zr.Close()
+		body = zr
+	}
+
+	dbs := make(map[string]gnotesDB)
+	tr := tar.NewReader(body)
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot read android backup archive: %w", err)
+		}
+
+		name, suffix := splitSQLiteSidecar(hdr.Name)
+		if suffix == sqliteIndexSuffix || !isGNotesDatabase(name) {
+			continue
+		}
+
+		data, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read %s from android backup: %w", hdr.Name, err)
+		}
+
+		db := dbs[name]
+		if suffix == "" {
+			db.data = data
+		} else {
+			if db.sidecars == nil {
+				db.sidecars = make(map[string][]byte)
+			}
+			db.sidecars[suffix] = data
+		}
+		dbs[name] = db
+	}
+
+	// write-ahead logs and journals are of no use without their database
+	for name, db := range dbs {
+		if db.data == nil {
+			delete(dbs, name)
+		}
+	}
+
+	return dbs, nil
+}
+
+// isGNotesDatabase returns true if the provided path within an android backup represents a gnotes database,
+// which is stored at apps/<package>/db/<name>
+func isGNotesDatabase(p string) bool {
+	parts := strings.Split(path.Clean(p), "/")
+	if len(parts) != 4 || parts[0] != "apps" || parts[2] != "db" {
+		return false
+	}
+
+	if !strings.Contains(strings.ToLower(parts[1]), gnotesPackageName) {
+		return false
+	}
+
+	_, suffix := splitSQLiteSidecar(parts[3])
+
+	return suffix == ""
+}
+
+// splitSQLiteSidecar splits the provided path into the path of the sqlite database that it belongs to and the suffix
+// of its sidecar file, where the suffix is empty if the path does not represent a sidecar file
+func splitSQLiteSidecar(p string) (string, string) {
+	for _, suffix := range append([]string{sqliteIndexSuffix}, sqliteChangeSuffixes...) {
+		if strings.HasSuffix(p, suffix) {
+			return strings.TrimSuffix(p, suffix), suffix
+		}
+	}
+
+	return p, ""
+}
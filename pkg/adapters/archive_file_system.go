@@ -0,0 +1,501 @@
+package adapters
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"reorg/pkg/domain"
+	"sort"
+	"strings"
+)
+
+// ErrNotArchive is returned when a path does not represent a supported archive
+var ErrNotArchive = errors.New("not a zip or tar archive")
+
+// errArchiveNotExist is returned when a path within an archive does not exist
+var errArchiveNotExist = errors.New("no such file or directory in archive")
+
+// errArchiveReadOnly is returned when attempting to modify a path within an archive
+var errArchiveReadOnly = errors.New("archive is read-only")
+
+// archive magic numbers
+var (
+	zipMagic  = []byte("PK\x03\x04")
+	gzipMagic = []byte{0x1f, 0x8b}
+	tarMagic  = []byte("ustar")
+)
+
+// tarMagicOffset defines the offset of the magic number within a tar header
+const tarMagicOffset = 257
+
+// archiveItem represents a file or directory as listed by an archive, prior to being indexed
+type archiveItem struct {
+	name    string
+	dir     bool
+	size    int64
+	zipFile *zip.File
+	offset  int64
+}
+
+// archiveEntry represents a single file or directory within an archive
+type archiveEntry struct {
+	name     string
+	dir      bool
+	size     int64
+	children map[string]*archiveEntry
+	zipFile  *zip.File // source of file contents within a zip archive
+	offset   int64     // offset of file contents within a tar archive
+}
+
+// ArchiveFileSystem implements FileSystem for the contents of a zip or tar archive, which is presented as
+// a read-only directory at the path of the archive itself
+//
+// Paths outside of the archive are delegated to the embedded FileSystem.
+type ArchiveFileSystem struct {
+	domain.FileSystem
+	root  string                   // full-qualified path to the archive
+	index map[string]*archiveEntry // entries by full-qualified path
+	file  domain.File              // archive that file contents are read from
+	gz    *gzipTarReader           // reader of file contents within a gzipped tar archive, if any
+}
+
+// OpenArchive opens the zip, tar or gzipped tar archive at the provided path through the provided FileSystem,
+// detecting its type by content, returning ErrNotArchive if the path does not represent an archive
+//
+// Files are read from the archive as they are needed. A gzipped tar archive cannot be read out of order,
+// so it is decompressed in memory as files are read, restarting only when a file is read that was passed over
+// too long ago to still be cached. Nothing is decompressed to disk.
+// If the archive only contains a single directory, such as when a zip is made of an export directory itself,
+// its contents are presented in place of the directory.
+func OpenArchive(fs domain.FileSystem, p string) (*ArchiveFileSystem, error) {
+	root, err := fs.Abs(p)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse absolute path: %w", err)
+	}
+
+	info, err := fs.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.IsDir() {
+		return nil, ErrNotArchive
+	}
+
+	f, err := fs.Open(root)
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, tarMagicOffset+len(tarMagic))
+	n, _ := io.ReadFull(f, header)
+	header = header[:n]
+
+	a := &ArchiveFileSystem{
+		FileSystem: fs,
+		root:       root,
+		index:      map[string]*archiveEntry{root: {name: filepath.Base(root), dir: true, children: map[string]*archiveEntry{}}},
+		file:       f,
+	}
+
+	var items []archiveItem
+
+	switch {
+	case bytes.HasPrefix(header, zipMagic):
+		items, err = listZip(f, info.Size())
+	case bytes.HasPrefix(header, gzipMagic):
+		items, err = a.listGzipTar()
+	case len(header) > tarMagicOffset && bytes.HasPrefix(header[tarMagicOffset:], tarMagic):
+		if _, err = f.Seek(0, io.SeekStart); err == nil {
+			items, err = listTar(f)
+		}
+	default:
+		f.Close()
+		return nil, ErrNotArchive
+	}
+
+	if err != nil {
+		a.Close()
+		return nil, fmt.Errorf("cannot read archive %s: %w", root, err)
+	}
+
+	for _, item := range unwrapItems(items) {
+		e := a.add(item.name, item.dir)
+		if e != nil && !e.dir {
+			e.size = item.size
+			e.zipFile = item.zipFile
+			e.offset = item.offset
+		}
+	}
+
+	return a, nil
+}
+
+// listZip lists the entries of the zip archive read from the provided reader
+func listZip(r io.ReaderAt, size int64) ([]archiveItem, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []archiveItem
+
+	for _, zf := range zr.File {
+		items = append(items, archiveItem{
+			name:    zf.Name,
+			dir:     zf.FileInfo().IsDir(),
+			size:    int64(zf.UncompressedSize64),
+			zipFile: zf,
+		})
+	}
+
+	return items, nil
+}
+
+// listTar lists the entries of the tar archive read from the provided reader, along with the offset of each file's contents
+func listTar(r io.Reader) ([]archiveItem, error) {
+	// file contents immediately follow their header, so the number of bytes read once a header has been read
+	// is the offset of its file's contents
+	cr := &countingReader{r: r}
+	tr := tar.NewReader(cr)
+
+	var items []archiveItem
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return items, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			items = append(items, archiveItem{name: hdr.Name, dir: true})
+		case tar.TypeReg, tar.TypeRegA:
+			items = append(items, archiveItem{name: hdr.Name, size: hdr.Size, offset: cr.n})
+		}
+	}
+}
+
+// listGzipTar lists the entries of the gzipped tar archive by decompressing it in a single pass,
+// then prepares the archive's file contents to be read through gzip
+func (a *ArchiveFileSystem) listGzipTar() ([]archiveItem, error) {
+	if _, err := a.file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	gz, err := gzip.NewReader(a.file)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read gzip archive: %w", err)
+	}
+
+	items, err := listTar(gz)
+	if err != nil {
+		return nil, err
+	}
+
+	a.gz = newGzipTarReader(a.file, items)
+
+	return items, nil
+}
+
+// unwrapItems returns the provided items relative to the single directory that contains all of them, if any
+func unwrapItems(items []archiveItem) []archiveItem {
+	var wrapper string
+	var nested bool
+
+	for idx := range items {
+		items[idx].name = strings.TrimPrefix(path.Clean("/"+strings.ReplaceAll(items[idx].name, "\\", "/")), "/")
+		if items[idx].name == "" {
+			continue
+		}
+
+		parts := strings.SplitN(items[idx].name, "/", 2)
+
+		switch {
+		case wrapper == "":
+			wrapper = parts[0]
+		case wrapper != parts[0]:
+			return items
+		}
+
+		// wrapper is only a directory if an item is nested within it, or it is listed as a directory itself
+		if len(parts) > 1 || items[idx].dir {
+			nested = true
+		} else {
+			return items
+		}
+	}
+
+	if !nested {
+		return items
+	}
+
+	var unwrapped []archiveItem
+
+	for _, item := range items {
+		if item.name = strings.TrimPrefix(strings.TrimPrefix(item.name, wrapper), "/"); item.name != "" {
+			unwrapped = append(unwrapped, item)
+		}
+	}
+
+	return unwrapped
+}
+
+// add indexes an entry with the provided name, along with any parent directories that have not been indexed,
+// returning nil if the name does not represent a path within the archive
+func (a *ArchiveFileSystem) add(name string, dir bool) *archiveEntry {
+	name = path.Clean("/" + strings.ReplaceAll(name, "\\", "/"))
+	if name == "/" {
+		return nil
+	}
+
+	parent := a.index[a.root]
+	full := a.root
+
+	parts := strings.Split(strings.TrimPrefix(name, "/"), "/")
+	for idx, part := range parts {
+		full = filepath.Join(full, part)
+
+		e, ok := a.index[full]
+		if !ok {
+			e = &archiveEntry{name: part, dir: dir || idx < len(parts)-1}
+			if e.dir {
+				e.children = map[string]*archiveEntry{}
+			}
+			a.index[full] = e
+			parent.children[part] = e
+		}
+
+		parent = e
+	}
+
+	return parent
+}
+
+// lookup returns the entry at the provided path, and whether the path is within the archive
+func (a *ArchiveFileSystem) lookup(p string) (*archiveEntry, bool) {
+	p = filepath.Clean(p)
+	if p != a.root && !strings.HasPrefix(p, a.root+string(os.PathSeparator)) {
+		return nil, false
+	}
+
+	return a.index[p], true
+}
+
+// Close closes the underlying archive
+func (a *ArchiveFileSystem) Close() error {
+	return a.file.Close()
+}
+
+// ReadFile implements FileSystem.ReadFile()
+func (a *ArchiveFileSystem) ReadFile(p string) ([]byte, error) {
+	e, ok := a.lookup(p)
+	if !ok {
+		return a.FileSystem.ReadFile(p)
+	}
+
+	if e == nil || e.dir {
+		return nil, fmt.Errorf("%s: %w", p, errArchiveNotExist)
+	}
+
+	if e.zipFile == nil && a.gz != nil {
+		return a.gz.read(e.offset, e.size)
+	}
+
+	if e.zipFile == nil {
+		return ioutil.ReadAll(io.NewSectionReader(a.file, e.offset, e.size))
+	}
+
+	rc, err := e.zipFile.Open()
+	if err != nil {
+		return nil, fmt.Errorf("cannot open %s: %w", p, err)
+	}
+	defer rc.Close()
+
+	return ioutil.ReadAll(rc)
+}
+
+// Open implements FileSystem.Open()
+//
+// Files within a zip or gzipped tar archive are read into memory, as compressed entries cannot be read randomly.
+func (a *ArchiveFileSystem) Open(p string) (domain.File, error) {
+	e, ok := a.lookup(p)
+	if !ok {
+		return a.FileSystem.Open(p)
+	}
+
+	if e != nil && !e.dir && e.zipFile == nil && a.gz == nil {
+		return &archiveFile{SectionReader: io.NewSectionReader(a.file, e.offset, e.size)}, nil
+	}
+
+	data, err := a.ReadFile(p)
+	if err != nil {
+		return nil, err
+	}
+
+	return &archiveFile{SectionReader: io.NewSectionReader(bytes.NewReader(data), 0, int64(len(data)))}, nil
+}
+
+// WriteFile implements FileSystem.WriteFile()
+func (a *ArchiveFileSystem) WriteFile(p string, data []byte, perm uint32) error {
+	if _, ok := a.lookup(p); ok {
+		return fmt.Errorf("cannot write %s: %w", p, errArchiveReadOnly)
+	}
+
+	return a.FileSystem.WriteFile(p, data, perm)
+}
+
+// ReadDir implements FileSystem.ReadDir()
+func (a *ArchiveFileSystem) ReadDir(p string) ([]domain.FileInfo, error) {
+	e, ok := a.lookup(p)
+	if !ok {
+		return a.FileSystem.ReadDir(p)
+	}
+
+	if e == nil || !e.dir {
+		return nil, fmt.Errorf("%s: %w", p, errArchiveNotExist)
+	}
+
+	var names []string
+	for name := range e.children {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var fis []domain.FileInfo
+	for _, name := range names {
+		fis = append(fis, &archiveFileInfo{e: e.children[name]})
+	}
+
+	return fis, nil
+}
+
+// DirExists implements FileSystem.DirExists()
+func (a *ArchiveFileSystem) DirExists(p string) error {
+	e, ok := a.lookup(p)
+	if !ok {
+		return a.FileSystem.DirExists(p)
+	}
+
+	if e == nil {
+		return fmt.Errorf("path does not exist: %s", p)
+	}
+
+	if !e.dir {
+		return fmt.Errorf("path is not a directory: %s", p)
+	}
+
+	return nil
+}
+
+// IsNotExist implements FileSystem.IsNotExist()
+func (a *ArchiveFileSystem) IsNotExist(err error) bool {
+	return errors.Is(err, errArchiveNotExist) || a.FileSystem.IsNotExist(err)
+}
+
+// Stat implements FileSystem.Stat()
+func (a *ArchiveFileSystem) Stat(p string) (domain.FileInfo, error) {
+	e, ok := a.lookup(p)
+	if !ok {
+		return a.FileSystem.Stat(p)
+	}
+
+	if e == nil {
+		return nil, fmt.Errorf("%s: %w", p, errArchiveNotExist)
+	}
+
+	return &archiveFileInfo{e: e}, nil
+}
+
+// Mkdir implements FileSystem.Mkdir()
+func (a *ArchiveFileSystem) Mkdir(p string, perm uint32) error {
+	if _, ok := a.lookup(p); ok {
+		return fmt.Errorf("cannot make directory %s: %w", p, errArchiveReadOnly)
+	}
+
+	return a.FileSystem.Mkdir(p, perm)
+}
+
+// MkdirAll implements FileSystem.MkdirAll()
+func (a *ArchiveFileSystem) MkdirAll(p string, perm uint32) error {
+	if _, ok := a.lookup(p); ok {
+		return fmt.Errorf("cannot make directory %s: %w", p, errArchiveReadOnly)
+	}
+
+	return a.FileSystem.MkdirAll(p, perm)
+}
+
+// RemoveAll implements FileSystem.RemoveAll()
+func (a *ArchiveFileSystem) RemoveAll(p string) error {
+	if _, ok := a.lookup(p); ok {
+		return fmt.Errorf("cannot remove %s: %w", p, errArchiveReadOnly)
+	}
+
+	return a.FileSystem.RemoveAll(p)
+}
+
+// Rename implements FileSystem.Rename()
+func (a *ArchiveFileSystem) Rename(oldPath, newPath string) error {
+	for _, p := range []string{oldPath, newPath} {
+		if _, ok := a.lookup(p); ok {
+			return fmt.Errorf("cannot rename %s: %w", p, errArchiveReadOnly)
+		}
+	}
+
+	return a.FileSystem.Rename(oldPath, newPath)
+}
+
+// archiveFile implements File for the contents of a file within an archive
+type archiveFile struct {
+	*io.SectionReader
+}
+
+// Close implements File.Close()
+func (a *archiveFile) Close() error {
+	return nil
+}
+
+// countingReader counts the bytes that are read from the wrapped reader
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+// Read implements io.Reader
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// archiveFileInfo implements FileInfo for an entry within an archive
+type archiveFileInfo struct {
+	domain.FileInfo
+	e *archiveEntry
+}
+
+// IsDir implements FileInfo.IsDir()
+func (a *archiveFileInfo) IsDir() bool {
+	return a.e.dir
+}
+
+// Name implements FileInfo.Name()
+func (a *archiveFileInfo) Name() string {
+	return a.e.name
+}
+
+// Size implements FileInfo.Size()
+func (a *archiveFileInfo) Size() int64 {
+	return a.e.size
+}
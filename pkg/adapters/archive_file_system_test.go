@@ -0,0 +1,77 @@
+package adapters
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestArchiveFileSystem_GzipTar(t *testing.T) {
+	const total = 20
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+
+	for idx := 0; idx < total; idx++ {
+		content := []byte(fmt.Sprintf("note %d", idx))
+		hdr := &tar.Header{Name: fmt.Sprintf("export/Other/%d/content.html", idx), Mode: 0644, Size: int64(len(content))}
+
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if _, err := tw.Write(content); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	p := filepath.Join(t.TempDir(), "export.tar.gz")
+	if err := ioutil.WriteFile(p, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	a, err := OpenArchive(&OsFileSystem{}, p)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer a.Close()
+
+	// files are read in reverse, so that every read precedes the previous one
+	for idx := total - 1; idx >= 0; idx-- {
+		got, err := a.ReadFile(filepath.Join(p, "Other", fmt.Sprint(idx), "content.html"))
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if want := fmt.Sprintf("note %d", idx); string(got) != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	}
+
+	// once nothing is cached, the archive is decompressed again from its beginning
+	a.gz.cache = map[int64][]byte{}
+	a.gz.order = nil
+	a.gz.cached = 0
+
+	for idx := total - 1; idx >= 0; idx-- {
+		got, err := a.ReadFile(filepath.Join(p, "Other", fmt.Sprint(idx), "content.html"))
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if want := fmt.Sprintf("note %d", idx); string(got) != want {
+			t.Errorf("uncached: got %q, want %q", got, want)
+		}
+	}
+}
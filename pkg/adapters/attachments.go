@@ -0,0 +1,64 @@
+package adapters
+
+import (
+	"fmt"
+	"reorg/pkg/domain"
+	"sync"
+)
+
+// writeAttachments copies each of the provided Note's attachments to the provided parent directory
+func writeAttachments(m *sync.Mutex, f *domain.FileSystemService, parentDir string, n domain.Note) error {
+	for _, a := range n.Attachments {
+		data, err := readAttachment(f, n, a)
+		if err != nil {
+			return fmt.Errorf("cannot read attachment %s: %w", a.Path, err)
+		}
+
+		dest, err := f.ParseAbsPath(parentDir, a.Path)
+		if err != nil {
+			return fmt.Errorf("cannot parse absolute path: %w", err)
+		}
+
+		if err := createDirAll(m, f, f.ParseDir(dest)); err != nil {
+			return err
+		}
+
+		if err := f.WriteFile(dest, data, 0644); err != nil {
+			return fmt.Errorf("cannot write attachment %s: %w", a.Path, err)
+		}
+	}
+
+	return nil
+}
+
+// readAttachment reads the content of the provided Attachment, from its inlined data,
+// its original source file, or otherwise relative to the parent directory of the provided Note
+func readAttachment(f *domain.FileSystemService, n domain.Note, a domain.Attachment) ([]byte, error) {
+	if a.Data != nil {
+		return a.Data, nil
+	}
+
+	src := a.SourcePath
+	if src == "" {
+		var err error
+		if src, err = f.ParseAbsPath(n.ParentDir, a.Path); err != nil {
+			return nil, fmt.Errorf("cannot parse absolute path: %w", err)
+		}
+	}
+
+	return f.ReadFile(src)
+}
+
+// createDirAll attempts to create the provided path as a directory along with any parents if it doesn't exist
+func createDirAll(m *sync.Mutex, f *domain.FileSystemService, path string) error {
+	m.Lock()
+	defer m.Unlock()
+
+	if err := f.DirExists(path); err != nil {
+		if err := f.MakeDirAll(path); err != nil {
+			return fmt.Errorf("cannot make directory %s: %w", path, err)
+		}
+	}
+
+	return nil
+}
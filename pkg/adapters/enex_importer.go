@@ -0,0 +1,299 @@
+package adapters
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"path"
+	"regexp"
+	"reorg/pkg/domain"
+	"strings"
+	"time"
+)
+
+// enexExt defines the file extension of an evernote export file
+const enexExt = ".enex"
+
+// enexTimestampLayout defines the layout of timestamps within an evernote export file
+const enexTimestampLayout = "20060102T150405Z"
+
+// enexNote represents a single note within an evernote export file
+type enexNote struct {
+	Title     string         `xml:"title"`
+	Content   string         `xml:"content"`
+	Created   string         `xml:"created"`
+	Updated   string         `xml:"updated"`
+	Resources []enexResource `xml:"resource"`
+}
+
+// enexResource represents a file attached to a note within an evernote export file
+type enexResource struct {
+	Data     string `xml:"data"`
+	Mime     string `xml:"mime"`
+	FileName string `xml:"resource-attributes>file-name"`
+}
+
+// enexNoteSummary represents the fields of a note within an evernote export file that identify it,
+// which are decoded without holding its resources in memory
+type enexNoteSummary struct {
+	Title   string `xml:"title"`
+	Content string `xml:"content"`
+	Created string `xml:"created"`
+}
+
+// enexEntry represents a note within an evernote export file alongside its location
+type enexEntry struct {
+	path   string // path to the export file
+	offset int64  // byte offset of the note element within the export file
+	id     string // identifier of the note
+}
+
+// enexIDHashLen defines the number of hex characters of the hash of a note that is used within its id
+const enexIDHashLen = 12
+
+// enexUnsafeNameChars matches the characters of a resource file name that cannot be written on every filesystem
+var enexUnsafeNameChars = regexp.MustCompile(`[<>:"|?*\x00-\x1f]`)
+
+// ENEXImporter imports Notes from evernote export files, each of which contains the notes of a single notebook
+type ENEXImporter struct {
+	domain.Importer
+	Files   *domain.FileSystemService
+	Notes   *domain.NoteService
+	entries map[string]enexEntry // entries by source, populated by Sources
+}
+
+// Sources implements domain.Importer
+//
+// The provided path is either an export file or a directory of export files.
+// Each source is the path to an export file suffixed by the id of a note within it, which is derived from the note's
+// notebook, title, created timestamp and content so that it does not depend upon the note's position.
+//
+// Only the location of each note is retained, and each note is decoded along with its resources once it is imported.
+func (e *ENEXImporter) Sources(p string) ([]string, error) {
+	files := []string{p}
+
+	if err := e.Files.DirExists(p); err == nil {
+		children, err := e.Files.GetChildPaths(p, &domain.IsNotDir{})
+		if err != nil {
+			return nil, fmt.Errorf("validation error: %w", err)
+		}
+
+		files = nil
+		for _, c := range children {
+			if strings.EqualFold(path.Ext(c), enexExt) {
+				files = append(files, c)
+			}
+		}
+	}
+
+	e.entries = make(map[string]enexEntry)
+
+	var sources []string
+
+	for _, f := range files {
+		entries, err := e.scanFile(f)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, entry := range entries {
+			source := fmt.Sprintf("%s#%s", f, entry.id)
+			e.entries[source] = entry
+			sources = append(sources, source)
+		}
+	}
+
+	return sources, nil
+}
+
+// scanFile returns the location and id of each note within the evernote export file at the provided path
+func (e *ENEXImporter) scanFile(p string) ([]enexEntry, error) {
+	f, err := e.Files.Open(p)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open file %s: %w", p, err)
+	}
+	defer f.Close()
+
+	notebook := enexNotebook(e.Files, p)
+
+	var entries []enexEntry
+	seen := make(map[string]int)
+
+	dec := xml.NewDecoder(f)
+	dec.Strict = false
+
+	for {
+		offset := dec.InputOffset()
+
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot xml decode file %s: %w", p, err)
+		}
+
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "note" {
+			continue
+		}
+
+		var n enexNoteSummary
+		if err := dec.DecodeElement(&n, &start); err != nil {
+			return nil, fmt.Errorf("cannot xml decode note in file %s: %w", p, err)
+		}
+
+		id := enexNoteID(notebook, n)
+
+		// identical notes are distinguished by their occurrence
+		seen[id]++
+		if seen[id] > 1 {
+			id = fmt.Sprintf("%s-%d", id, seen[id])
+		}
+
+		entries = append(entries, enexEntry{path: p, offset: offset, id: id})
+	}
+
+	return entries, nil
+}
+
+// readNote decodes the note at the provided location within an evernote export file
+func (e *ENEXImporter) readNote(entry enexEntry) (enexNote, error) {
+	f, err := e.Files.Open(entry.path)
+	if err != nil {
+		return enexNote{}, fmt.Errorf("cannot open file %s: %w", entry.path, err)
+	}
+	defer f.Close()
+
+	if _, err := f.Seek(entry.offset, io.SeekStart); err != nil {
+		return enexNote{}, fmt.Errorf("cannot seek within file %s: %w", entry.path, err)
+	}
+
+	dec := xml.NewDecoder(f)
+	dec.Strict = false
+
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return enexNote{}, fmt.Errorf("cannot xml decode file %s: %w", entry.path, err)
+		}
+
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "note" {
+			continue
+		}
+
+		var n enexNote
+		if err := dec.DecodeElement(&n, &start); err != nil {
+			return enexNote{}, fmt.Errorf("cannot xml decode note in file %s: %w", entry.path, err)
+		}
+
+		return n, nil
+	}
+}
+
+// Import implements domain.Importer
+func (e *ENEXImporter) Import(source string) (domain.Note, error) {
+	entry, ok := e.entries[source]
+	if !ok {
+		return domain.Note{}, fmt.Errorf("cannot find note: %s", source)
+	}
+
+	note, err := e.readNote(entry)
+	if err != nil {
+		return domain.Note{}, err
+	}
+
+	exported := domain.ExportedNote{
+		ID:           entry.id,
+		OriginalPath: entry.path,
+		SourceFolder: enexNotebook(e.Files, entry.path),
+		Title:        note.Title,
+		Body:         domain.NormaliseENML(note.Content),
+		HTML:         true,
+	}
+
+	if exported.CreatedAt, err = parseENEXTimestamp(note.Created); err != nil {
+		return domain.Note{}, fmt.Errorf("cannot parse created timestamp: %w", err)
+	}
+
+	if exported.ModifiedAt, err = parseENEXTimestamp(note.Updated); err != nil {
+		return domain.Note{}, fmt.Errorf("cannot parse modified timestamp: %w", err)
+	}
+
+	// notes that have never been edited are exported without an updated timestamp, so this is not worth a warning
+	if exported.ModifiedAt.IsZero() {
+		exported.ModifiedAt = exported.CreatedAt
+	}
+
+	names := make(map[string]bool)
+
+	for idx, r := range note.Resources {
+		data, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(r.Data), ""))
+		if err != nil {
+			return domain.Note{}, fmt.Errorf("cannot decode resource %d: %w", idx+1, err)
+		}
+
+		exported.Attachments = append(exported.Attachments, domain.Attachment{
+			Path:     path.Join(domain.AttachmentDir(exported.SourceFolder, entry.id), enexResourceName(r, idx, names)),
+			MimeType: r.Mime,
+			Size:     int64(len(data)),
+			Data:     data,
+		})
+	}
+
+	return e.Notes.ParseFromExportedNote(exported)
+}
+
+// enexNotebook returns the name of the notebook of the evernote export file at the provided path,
+// which is the name of the file without its extension
+func enexNotebook(f *domain.FileSystemService, p string) string {
+	return strings.TrimSuffix(f.ParseBase(p), path.Ext(p))
+}
+
+// enexNoteID returns the id of a note of the provided notebook, derived from its title, created timestamp and content
+func enexNoteID(notebook string, n enexNoteSummary) string {
+	sum := sha1.Sum([]byte(strings.Join([]string{n.Title, strings.TrimSpace(n.Created), n.Content}, "\n")))
+	return fmt.Sprintf("%s-%s", domain.Slugify(notebook), hex.EncodeToString(sum[:])[:enexIDHashLen])
+}
+
+// enexResourceName returns the name that the provided resource of a note is written with, which is its base file name
+// with any unsafe characters replaced, made unique amongst the names already taken by the note's other resources
+//
+// Resources without a usable file name are named by their position and mime type.
+func enexResourceName(r enexResource, idx int, taken map[string]bool) string {
+	name := path.Base(strings.ReplaceAll(r.FileName, "\\", "/"))
+	name = strings.TrimSpace(strings.TrimLeft(name, ". "))
+	name = enexUnsafeNameChars.ReplaceAllString(name, "_")
+
+	if name == "" || name == "/" {
+		name = fmt.Sprintf("resource_%d", idx+1)
+		if exts, err := mime.ExtensionsByType(r.Mime); err == nil && len(exts) > 0 {
+			name += exts[0]
+		}
+	}
+
+	ext := path.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+
+	for n := 2; taken[strings.ToLower(name)]; n++ {
+		name = fmt.Sprintf("%s_%d%s", stem, n, ext)
+	}
+
+	taken[strings.ToLower(name)] = true
+
+	return name
+}
+
+// parseENEXTimestamp parses the provided evernote timestamp, returning the zero time if empty
+func parseENEXTimestamp(raw string) (time.Time, error) {
+	if raw = strings.TrimSpace(raw); raw == "" {
+		return time.Time{}, nil
+	}
+
+	return time.Parse(enexTimestampLayout, raw)
+}
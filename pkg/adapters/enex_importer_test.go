@@ -0,0 +1,158 @@
+package adapters
+
+import (
+	"encoding/base64"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"reorg/pkg/domain"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// enexNoteFixture returns a note element of an evernote export file
+func enexNoteFixture(title, content, updated string, resources ...string) string {
+	var updatedElem string
+	if updated != "" {
+		updatedElem = fmt.Sprintf("<updated>%s</updated>", updated)
+	}
+
+	return fmt.Sprintf(
+		`<note><title>%s</title><content><![CDATA[<en-note><div>%s</div></en-note>]]></content><created>20210325T100000Z</created>%s%s</note>`,
+		title, content, updatedElem, strings.Join(resources, ""),
+	)
+}
+
+// enexResourceFixture returns a resource element of an evernote export file
+func enexResourceFixture(fileName, mimeType string, data []byte) string {
+	var attrs string
+	if fileName != "" {
+		attrs = fmt.Sprintf("<resource-attributes><file-name>%s</file-name></resource-attributes>", fileName)
+	}
+
+	return fmt.Sprintf(
+		`<resource><data encoding="base64">%s</data><mime>%s</mime>%s</resource>`,
+		base64.StdEncoding.EncodeToString(data), mimeType, attrs,
+	)
+}
+
+// enexExportFixture returns an evernote export file of the provided note elements
+func enexExportFixture(notes ...string) string {
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?><en-export>%s</en-export>`, strings.Join(notes, ""))
+}
+
+// newENEXImporter returns a new ENEXImporter that reads from the os
+func newENEXImporter() *ENEXImporter {
+	fs := &OsFileSystem{}
+	return &ENEXImporter{
+		Files: domain.NewFileSystemService(fs),
+		Notes: domain.NewNoteService(fs),
+	}
+}
+
+func TestENEXImporter_StableIDs(t *testing.T) {
+	shopping := enexNoteFixture("Shopping", "eggs", "20210326T113000Z")
+	holiday := enexNoteFixture("Holiday", "beach", "20210326T113000Z")
+
+	// the same notes, in a different order
+	dir := writeFixtures(t, map[string]string{
+		"a/Work.enex": enexExportFixture(shopping, holiday, shopping),
+		"b/Work.enex": enexExportFixture(holiday, shopping, shopping),
+	})
+
+	ids := func(sub string) []string {
+		im := newENEXImporter()
+
+		sources, err := im.Sources(filepath.Join(dir, sub))
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		var ids []string
+		for _, s := range sources {
+			n, err := im.Import(s)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			ids = append(ids, n.ID)
+		}
+
+		sort.Strings(ids)
+		return ids
+	}
+
+	a, b := ids("a"), ids("b")
+
+	if len(a) != 3 || a[0] == a[1] || a[1] == a[2] || a[0] == a[2] {
+		t.Fatalf("got ids %q, want three distinct ids", a)
+	}
+
+	if !reflect.DeepEqual(a, b) {
+		t.Errorf("got ids %q, want %q", b, a)
+	}
+
+	for _, id := range a {
+		if !strings.HasPrefix(id, "work-") {
+			t.Errorf("got id %q, want id prefixed by the notebook", id)
+		}
+	}
+}
+
+func TestENEXImporter_Resources(t *testing.T) {
+	note := enexNoteFixture("Receipts", "scanned", "",
+		enexResourceFixture("img.png", "image/png", []byte("first")),
+		enexResourceFixture("IMG.png", "image/png", []byte("second")),
+		enexResourceFixture("", "image/png", []byte("third")),
+		enexResourceFixture(`..\scans\a:b.pdf`, "application/pdf", []byte("fourth")),
+	)
+
+	dir := writeFixtures(t, map[string]string{"Work.enex": enexExportFixture(note)})
+	im := newENEXImporter()
+
+	sources, err := im.Sources(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(sources) != 1 {
+		t.Fatalf("got sources %q, want 1", sources)
+	}
+
+	n, err := im.Import(sources[0])
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	attachmentDir := domain.AttachmentDir("Work", n.ID)
+	want := []struct {
+		name string
+		data string
+	}{
+		{"img.png", "first"},
+		{"IMG_2.png", "second"},
+		{"resource_3.png", "third"},
+		{"a_b.pdf", "fourth"},
+	}
+
+	if len(n.Attachments) != len(want) {
+		t.Fatalf("got %d attachments, want %d", len(n.Attachments), len(want))
+	}
+
+	for idx, w := range want {
+		a := n.Attachments[idx]
+		if wantPath := attachmentDir + "/" + w.name; a.Path != wantPath || string(a.Data) != w.data {
+			t.Errorf("attachment %d: got path %q with data %q, want path %q with data %q", idx, a.Path, a.Data, wantPath, w.data)
+		}
+	}
+
+	// notes that have never been edited have no updated timestamp, which is not worth a warning
+	if want := time.Date(2021, 3, 25, 10, 0, 0, 0, time.UTC); !n.CreatedAt.Equal(want) || !n.ModifiedAt.Equal(want) {
+		t.Errorf("got timestamps %s and %s, want both %s", n.CreatedAt, n.ModifiedAt, want)
+	}
+
+	if len(n.Warnings) != 0 {
+		t.Errorf("got warnings %q, want none", n.Warnings)
+	}
+}
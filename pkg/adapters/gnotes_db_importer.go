@@ -0,0 +1,507 @@
+package adapters
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"reorg/pkg/domain"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	// pure-go sqlite driver, which does not require cgo
+	_ "modernc.org/sqlite"
+)
+
+// sqliteMagic defines the header of an sqlite database file
+var sqliteMagic = []byte("SQLite format 3\x00")
+
+// htmlContentRgx matches content that contains html markup
+var htmlContentRgx = regexp.MustCompile(`(?i)<(p|div|br|span|b|i|u|a|ul|ol|li|img|input|font)[\s>/]`)
+
+// gnotesDBColumns defines the candidate names of each column that is read from the gnotes database,
+// normalised to lower case without underscores
+var gnotesDBColumns = struct {
+	noteTables, folderTables              []string
+	id, title, content, created, modified []string
+	folder, folderID, folderName          []string
+	starred, archived, pinned             []string
+}{
+	noteTables:   []string{"notes", "note", "gnotes", "memos", "memo"},
+	folderTables: []string{"folders", "folder", "categories", "category"},
+	id:           []string{"id", "noteid"},
+	title:        []string{"title", "subject", "name"},
+	content:      []string{"content", "body", "text", "note"},
+	created:      []string{"createdtime", "createtime", "created", "createdat", "datecreated", "ctime"},
+	modified:     []string{"modifiedtime", "modifytime", "modified", "modifiedat", "updatedtime", "updatetime", "updatedat", "datemodified", "mtime"},
+	folder:       []string{"folderid", "folder", "categoryid", "category"},
+	folderID:     []string{"id", "folderid", "categoryid"},
+	folderName:   []string{"name", "title", "foldername"},
+	starred:      []string{"starred", "isstarred", "star", "isstar", "favorite", "isfavorite", "favourite"},
+	archived:     []string{"archived", "isarchived", "archive"},
+	pinned:       []string{"pinned", "ispinned", "pin", "top", "istop", "sticky"},
+}
+
+// gnotesDBTimestampLayouts defines the layouts of timestamps that are stored as text within the gnotes database,
+// which are attempted after the configured layouts
+var gnotesDBTimestampLayouts = []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02 15:04"}
+
+// gnotesDBNote represents a note read from a gnotes database
+type gnotesDBNote struct {
+	path     string
+	id       string
+	title    string
+	content  string
+	folder   string
+	created  time.Time
+	modified time.Time
+	starred  bool
+	archived bool
+	pinned   bool
+}
+
+// gnotesDB represents the contents of a gnotes database file, along with those of its write-ahead log or rollback journal,
+// which hold changes that have not yet been written to the database file
+type gnotesDB struct {
+	data     []byte
+	sidecars map[string][]byte // contents of each sidecar file, keyed by suffix
+}
+
+// GNotesDBImporter imports Notes from the internal sqlite database of gnotes,
+// either directly or from within an android backup (as created by adb backup)
+type GNotesDBImporter struct {
+	domain.Importer
+	Files   *domain.FileSystemService
+	Notes   *domain.NoteService
+	entries map[string]gnotesDBNote // entries by source, populated by Sources
+}
+
+// Sources implements domain.Importer
+//
+// Each source is the path to the database or backup file suffixed by the id of a note within it. The name of
+// the database is included for a backup, which may contain more than one database.
+//
+// Changes that are held in the write-ahead log or rollback journal of a database are read along with it.
+func (g *GNotesDBImporter) Sources(path string) ([]string, error) {
+	b, err := g.Files.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read file %s: %w", path, err)
+	}
+
+	dbs := map[string]gnotesDB{path: {data: b}}
+	backup := !bytes.HasPrefix(b, sqliteMagic)
+
+	if backup {
+		if dbs, err = readAndroidBackup(b); err != nil {
+			return nil, fmt.Errorf("cannot read %s as gnotes database or android backup: %w", path, err)
+		}
+
+		if len(dbs) == 0 {
+			return nil, fmt.Errorf("cannot find gnotes database in android backup: %s", path)
+		}
+	} else if dbs[path], err = g.readSidecars(path, b); err != nil {
+		return nil, err
+	}
+
+	// read databases in a consistent order
+	var names []string
+	for name := range dbs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	g.entries = make(map[string]gnotesDBNote)
+
+	var sources []string
+	var readErr error
+
+	for _, name := range names {
+		notes, err := readGNotesDatabase(dbs[name], g.parseTimestamp)
+		if err != nil {
+			// backups may contain other databases, so only fail if no database contains notes
+			readErr = fmt.Errorf("cannot read notes from database %s: %w", name, err)
+			continue
+		}
+
+		for _, n := range notes {
+			source := fmt.Sprintf("%s#%s", path, n.id)
+			if backup {
+				source = fmt.Sprintf("%s#%s#%s", path, name, n.id)
+			}
+
+			// notes of different databases may share an id, so their ids are qualified by their database
+			if len(dbs) > 1 {
+				n.id = fmt.Sprintf("%s-%s", domain.Slugify(g.Files.ParseBase(name)), n.id)
+			}
+
+			n.path = path
+			g.entries[source] = n
+			sources = append(sources, source)
+		}
+	}
+
+	if len(sources) == 0 && readErr != nil {
+		return nil, readErr
+	}
+
+	return sources, nil
+}
+
+// readSidecars returns the provided contents of the database at the provided path, along with the contents of its
+// write-ahead log or rollback journal, if either exists
+func (g *GNotesDBImporter) readSidecars(path string, b []byte) (gnotesDB, error) {
+	db := gnotesDB{data: b, sidecars: make(map[string][]byte)}
+
+	for _, suffix := range sqliteChangeSuffixes {
+		sidecar, err := g.Files.ReadFile(path + suffix)
+		if err != nil {
+			if g.Files.IsNotExist(err) {
+				continue
+			}
+			return gnotesDB{}, fmt.Errorf("cannot read file %s: %w", path+suffix, err)
+		}
+
+		db.sidecars[suffix] = sidecar
+	}
+
+	return db, nil
+}
+
+// Import implements domain.Importer
+func (g *GNotesDBImporter) Import(source string) (domain.Note, error) {
+	entry, ok := g.entries[source]
+	if !ok {
+		return domain.Note{}, fmt.Errorf("cannot find note: %s", source)
+	}
+
+	return g.Notes.ParseFromExportedNote(domain.ExportedNote{
+		ID:           entry.id,
+		OriginalPath: entry.path,
+		SourceFolder: entry.folder,
+		Title:        entry.title,
+		Body:         entry.content,
+		HTML:         htmlContentRgx.MatchString(entry.content),
+		CreatedAt:    entry.created,
+		ModifiedAt:   entry.modified,
+		Starred:      entry.starred,
+		Archived:     entry.archived,
+		Pinned:       entry.pinned,
+	})
+}
+
+// parseTimestamp parses the provided database value as a timestamp, returning the zero time if empty
+//
+// Numeric values are treated as milliseconds since the unix epoch if large enough, or otherwise seconds.
+// Text values are parsed using the note service's timestamp parser, so that they respect its location and layouts.
+func (g *GNotesDBImporter) parseTimestamp(v interface{}) (time.Time, error) {
+	var raw string
+
+	switch t := v.(type) {
+	case nil:
+		return time.Time{}, nil
+	case int64:
+		return unixToTime(t), nil
+	case float64:
+		return unixToTime(int64(t)), nil
+	case []byte:
+		raw = string(t)
+	case string:
+		raw = t
+	default:
+		return time.Time{}, fmt.Errorf("unsupported value: %v", v)
+	}
+
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return time.Time{}, nil
+	}
+
+	if i, err := strconv.ParseInt(raw, 10, 64); err == nil {
+		return unixToTime(i), nil
+	}
+
+	return g.Notes.ParseTimestamp(raw, gnotesDBTimestampLayouts...)
+}
+
+// readGNotesDatabase reads the notes from the provided sqlite database, parsing timestamps using the provided function
+//
+// The database is written to a temporary directory for the duration of reading, as sqlite cannot open a database from
+// memory, along with its write-ahead log or rollback journal so that sqlite applies any changes that they hold.
+func readGNotesDatabase(src gnotesDB, parseTimestamp func(v interface{}) (time.Time, error)) ([]gnotesDBNote, error) {
+	dir, err := ioutil.TempDir("", "gnotes-*")
+	if err != nil {
+		return nil, fmt.Errorf("cannot create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	p := filepath.Join(dir, "gnotes.db")
+
+	if err := ioutil.WriteFile(p, src.data, 0600); err != nil {
+		return nil, fmt.Errorf("cannot write temporary file: %w", err)
+	}
+
+	for suffix, data := range src.sidecars {
+		if err := ioutil.WriteFile(p+suffix, data, 0600); err != nil {
+			return nil, fmt.Errorf("cannot write temporary file: %w", err)
+		}
+	}
+
+	// database is opened for writing, as sqlite must be able to replay its write-ahead log or roll back its journal,
+	// although only the temporary copy is ever modified
+	db, err := sql.Open("sqlite", fmt.Sprintf("file:%s", p))
+	if err != nil {
+		return nil, fmt.Errorf("cannot open database: %w", err)
+	}
+	defer db.Close()
+
+	tables, err := readTableColumns(db)
+	if err != nil {
+		return nil, err
+	}
+
+	folders, err := readGNotesFolders(db, tables)
+	if err != nil {
+		return nil, err
+	}
+
+	return readGNotesNotes(db, tables, folders, parseTimestamp)
+}
+
+// readTableColumns returns the names of the columns of each table within the provided database, keyed by table name
+func readTableColumns(db *sql.DB) (map[string][]string, error) {
+	rows, err := db.Query(`SELECT name FROM sqlite_master WHERE type = 'table'`)
+	if err != nil {
+		return nil, fmt.Errorf("cannot query tables: %w", err)
+	}
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("cannot scan table: %w", err)
+		}
+		names = append(names, name)
+	}
+	rows.Close()
+
+	tables := make(map[string][]string)
+
+	for _, name := range names {
+		cols, err := db.Query(fmt.Sprintf(`SELECT * FROM %s LIMIT 0`, quoteIdent(name)))
+		if err != nil {
+			return nil, fmt.Errorf("cannot query table %s: %w", name, err)
+		}
+
+		tables[name], err = cols.Columns()
+		cols.Close()
+		if err != nil {
+			return nil, fmt.Errorf("cannot read columns of table %s: %w", name, err)
+		}
+	}
+
+	return tables, nil
+}
+
+// readGNotesFolders returns the names of the folders within the provided database, keyed by folder id
+//
+// Databases without a folders table return no folders.
+func readGNotesFolders(db *sql.DB, tables map[string][]string) (map[string]string, error) {
+	folders := make(map[string]string)
+
+	table, cols := findTable(tables, gnotesDBColumns.folderTables, gnotesDBColumns.folderID, gnotesDBColumns.folderName)
+	if table == "" {
+		return folders, nil
+	}
+
+	rows, err := db.Query(fmt.Sprintf(`SELECT %s, %s FROM %s`, quoteIdent(cols[0]), quoteIdent(cols[1]), quoteIdent(table)))
+	if err != nil {
+		return nil, fmt.Errorf("cannot query folders: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id, name sql.NullString
+		if err := rows.Scan(&id, &name); err != nil {
+			return nil, fmt.Errorf("cannot scan folder: %w", err)
+		}
+		folders[id.String] = name.String
+	}
+
+	return folders, rows.Err()
+}
+
+// readGNotesNotes returns the notes within the provided database, naming their folders using the provided folders
+// and parsing timestamps using the provided function
+func readGNotesNotes(db *sql.DB, tables map[string][]string, folders map[string]string, parseTimestamp func(v interface{}) (time.Time, error)) ([]gnotesDBNote, error) {
+	table, cols := findTable(tables, gnotesDBColumns.noteTables, gnotesDBColumns.id, gnotesDBColumns.content)
+	if table == "" {
+		return nil, errors.New("cannot find notes table")
+	}
+
+	// optional columns are selected as null if absent
+	selected := []string{quoteIdent(cols[0]), quoteIdent(cols[1])}
+	for _, optional := range []struct {
+		candidates []string
+		timestamp  bool
+	}{
+		{candidates: gnotesDBColumns.title},
+		{candidates: gnotesDBColumns.created, timestamp: true},
+		{candidates: gnotesDBColumns.modified, timestamp: true},
+		{candidates: gnotesDBColumns.folder},
+		{candidates: gnotesDBColumns.starred},
+		{candidates: gnotesDBColumns.archived},
+		{candidates: gnotesDBColumns.pinned},
+	} {
+		col := findColumn(tables[table], optional.candidates)
+		switch {
+		case col == "" || col == cols[0] || col == cols[1]:
+			selected = append(selected, "NULL")
+		case optional.timestamp:
+			// text is selected as a blob, so that the driver does not parse it as a utc timestamp
+			selected = append(selected, fmt.Sprintf("CASE WHEN typeof(%[1]s) = 'text' THEN CAST(%[1]s AS BLOB) ELSE %[1]s END", quoteIdent(col)))
+		default:
+			selected = append(selected, quoteIdent(col))
+		}
+	}
+
+	rows, err := db.Query(fmt.Sprintf(`SELECT %s FROM %s ORDER BY %s`, strings.Join(selected, ", "), quoteIdent(table), quoteIdent(cols[0])))
+	if err != nil {
+		return nil, fmt.Errorf("cannot query notes: %w", err)
+	}
+	defer rows.Close()
+
+	var notes []gnotesDBNote
+
+	for rows.Next() {
+		var id, content, title, folder sql.NullString
+		var created, modified, starred, archived, pinned interface{}
+
+		if err := rows.Scan(&id, &content, &title, &created, &modified, &folder, &starred, &archived, &pinned); err != nil {
+			return nil, fmt.Errorf("cannot scan note: %w", err)
+		}
+
+		n := gnotesDBNote{
+			id:       domain.Slugify(id.String),
+			title:    title.String,
+			content:  content.String,
+			folder:   folders[folder.String],
+			starred:  parseDBBool(starred),
+			archived: parseDBBool(archived),
+			pinned:   parseDBBool(pinned),
+		}
+
+		if n.created, err = parseTimestamp(created); err != nil {
+			return nil, fmt.Errorf("cannot parse created timestamp of note %s: %w", id.String, err)
+		}
+
+		if n.modified, err = parseTimestamp(modified); err != nil {
+			return nil, fmt.Errorf("cannot parse modified timestamp of note %s: %w", id.String, err)
+		}
+
+		notes = append(notes, n)
+	}
+
+	return notes, rows.Err()
+}
+
+// findTable returns the name of the first table whose name is one of the provided candidates and that has
+// a column matching each of the provided sets of candidate columns, along with the names of those columns
+//
+// Tables whose names match the same candidate are considered in order of name, so the same table is always found.
+func findTable(tables map[string][]string, names []string, columns ...[]string) (string, []string) {
+	var sorted []string
+	for table := range tables {
+		sorted = append(sorted, table)
+	}
+	sort.Strings(sorted)
+
+	for _, candidate := range names {
+		for _, table := range sorted {
+			if normaliseIdent(table) != candidate {
+				continue
+			}
+
+			cols := tables[table]
+
+			var found []string
+			for _, candidates := range columns {
+				if col := findColumn(cols, candidates); col != "" {
+					found = append(found, col)
+				}
+			}
+
+			if len(found) == len(columns) {
+				return table, found
+			}
+		}
+	}
+
+	return "", nil
+}
+
+// findColumn returns the first of the provided columns whose name is one of the provided candidates, preferring earlier candidates
+func findColumn(cols []string, candidates []string) string {
+	for _, candidate := range candidates {
+		for _, col := range cols {
+			if normaliseIdent(col) == candidate {
+				return col
+			}
+		}
+	}
+
+	return ""
+}
+
+// normaliseIdent normalises the provided identifier to lower case without underscores
+func normaliseIdent(ident string) string {
+	return strings.ReplaceAll(strings.ToLower(ident), "_", "")
+}
+
+// quoteIdent quotes the provided identifier for use within an sqlite query
+func quoteIdent(ident string) string {
+	return `"` + strings.ReplaceAll(ident, `"`, `""`) + `"`
+}
+
+// parseDBBool parses the provided database value as a boolean, where any non-zero number or truthy string is true
+// and anything else, including null, is false
+func parseDBBool(v interface{}) bool {
+	switch t := v.(type) {
+	case int64:
+		return t != 0
+	case float64:
+		return t != 0
+	case bool:
+		return t
+	case []byte:
+		return parseDBBool(string(t))
+	case string:
+		if i, err := strconv.ParseFloat(strings.TrimSpace(t), 64); err == nil {
+			return i != 0
+		}
+
+		b, _ := strconv.ParseBool(strings.TrimSpace(t))
+		return b
+	}
+
+	return false
+}
+
+// unixToTime returns the time represented by the provided milliseconds or seconds since the unix epoch,
+// or the zero time if not positive
+func unixToTime(i int64) time.Time {
+	switch {
+	case i <= 0:
+		return time.Time{}
+	case i > 1e11: // later than 5138 if seconds, so must be milliseconds
+		return time.Unix(0, i*int64(time.Millisecond))
+	}
+
+	return time.Unix(i, 0)
+}
@@ -0,0 +1,123 @@
+package adapters
+
+import (
+	"database/sql"
+	"path/filepath"
+	"reorg/pkg/domain"
+	"testing"
+	"time"
+)
+
+// gnotesDBFixture defines the statements that create a database fixture with the candidate table and column names
+// that the importer looks for
+var gnotesDBFixture = []string{
+	`CREATE TABLE folders (_id INTEGER PRIMARY KEY, name TEXT)`,
+	`CREATE TABLE notes (_id INTEGER PRIMARY KEY, title TEXT, content TEXT, folder_id INTEGER, created_time DATETIME, modified_time INTEGER, is_starred INTEGER, top INTEGER)`,
+	`INSERT INTO folders VALUES (1, 'Work'), (2, 'Trash')`,
+	`INSERT INTO notes VALUES (101, 'Budget', '<p>Q1 <b>figures</b></p>', 1, '2021-03-25 10:00:00', 1616754600000, 1, 0)`,
+	`INSERT INTO notes VALUES (102, '', 'eggs and milk', 2, '2021-03-25T10:00:00Z', NULL, 0, 1)`,
+}
+
+// newGNotesDBFixture writes a gnotes database fixture to a temporary directory, returning its path
+func newGNotesDBFixture(t *testing.T) string {
+	p := filepath.Join(t.TempDir(), "gnotes.db")
+
+	db, err := sql.Open("sqlite", "file:"+p)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer db.Close()
+
+	for _, stmt := range gnotesDBFixture {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	return p
+}
+
+func TestGNotesDBImporter(t *testing.T) {
+	p := newGNotesDBFixture(t)
+
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	tp, err := domain.NewTimestampParser(loc, domain.DefaultTimestampLayouts)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	fs := &OsFileSystem{}
+	im := &GNotesDBImporter{
+		Files: domain.NewFileSystemService(fs),
+		Notes: domain.NewNoteService(fs, domain.WithTimestampParser(tp)),
+	}
+
+	sources, err := im.Sources(p)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if want := []string{p + "#101", p + "#102"}; len(sources) != 2 || sources[0] != want[0] || sources[1] != want[1] {
+		t.Fatalf("got sources %q, want %q", sources, want)
+	}
+
+	budget, err := im.Import(sources[0])
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// text timestamps are parsed in the configured location, whereas numeric timestamps are absolute
+	if want := time.Date(2021, 3, 25, 10, 0, 0, 0, loc); !budget.CreatedAt.Equal(want) {
+		t.Errorf("got created %s, want %s", budget.CreatedAt, want)
+	}
+
+	if want := time.Unix(1616754600, 0); !budget.ModifiedAt.Equal(want) {
+		t.Errorf("got modified %s, want %s", budget.ModifiedAt, want)
+	}
+
+	if budget.ID != "101" || budget.Title != "Budget" || budget.SourceFolder != "Work" || !budget.Starred || budget.Pinned {
+		t.Errorf("got note %+v, want id 101 titled Budget in folder Work, starred and not pinned", budget)
+	}
+
+	if budget.Content != "Q1 figures\n" {
+		t.Errorf("got content %q, want %q", budget.Content, "Q1 figures\n")
+	}
+
+	shopping, err := im.Import(sources[1])
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if want := time.Date(2021, 3, 25, 10, 0, 0, 0, time.UTC); !shopping.CreatedAt.Equal(want) {
+		t.Errorf("got created %s, want %s", shopping.CreatedAt, want)
+	}
+
+	if shopping.Title != "eggs and milk" || shopping.SourceFolder != "Trash" || !shopping.Pinned {
+		t.Errorf("got note %+v, want inferred title in folder Trash, pinned", shopping)
+	}
+
+	if len(shopping.Warnings) != 1 {
+		t.Errorf("got warnings %q, want a warning for the missing modified timestamp", shopping.Warnings)
+	}
+}
+
+func TestFindTable(t *testing.T) {
+	tables := map[string][]string{
+		"memo":   {"id", "body"},
+		"me_mo":  {"_id", "content"},
+		"memos":  {"id"},
+		"folder": {"id", "name"},
+	}
+
+	for i := 0; i < 20; i++ {
+		table, cols := findTable(tables, []string{"memos", "memo"}, gnotesDBColumns.id, gnotesDBColumns.content)
+
+		if table != "me_mo" || len(cols) != 2 || cols[0] != "_id" || cols[1] != "content" {
+			t.Fatalf("run %d: got table %q with columns %q, want me_mo with columns _id and content", i, table, cols)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package adapters
+
+import (
+	"fmt"
+	"log"
+	"reorg/pkg/domain"
+)
+
+// gnotesRawFileName defines the filename of a raw gnotes note's source within its directory
+const gnotesRawFileName = "content.html"
+
+// GNotesImporter imports Notes from a GNotes export, whose folders each contain a directory per raw note
+type GNotesImporter struct {
+	domain.Importer
+	Files   *domain.FileSystemService
+	Notes   *domain.NoteService
+	Include []string // names of export folders to import (all folders if empty)
+	Exclude []string // names of export folders to skip
+}
+
+// Sources implements domain.Importer
+//
+// Each source is the path to a raw note directory.
+func (g *GNotesImporter) Sources(path string) ([]string, error) {
+	if err := g.Files.DirExists(path); err != nil {
+		return nil, fmt.Errorf("cannot find directory %s: %w", path, err)
+	}
+
+	validators := []domain.FileValidator{
+		&domain.IsDir{},
+		&domain.IsNotName{BaseNames: g.Exclude},
+	}
+
+	if len(g.Include) > 0 {
+		validators = append(validators, &domain.IsName{BaseNames: g.Include})
+	}
+
+	folders, err := g.Files.GetChildPaths(path, validators...)
+	if err != nil {
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+
+	var dirs []string
+
+	for _, f := range folders {
+		folderDirs, err := g.Files.GetChildPaths(f, &domain.IsDir{})
+		if err != nil {
+			return nil, fmt.Errorf("validation error: %w", err)
+		}
+
+		log.Printf("found %d directories in folder: %s", len(folderDirs), g.Files.ParseBase(f))
+
+		dirs = append(dirs, folderDirs...)
+	}
+
+	return dirs, nil
+}
+
+// DetectRawFormat implements domain.RawFormatDetector
+func (g *GNotesImporter) DetectRawFormat(sources []string) (domain.RawFormat, error) {
+	var rawPaths []string
+
+	for _, p := range sources {
+		pathToRaw, err := g.Files.ParseAbsPath(p, gnotesRawFileName)
+		if err != nil {
+			return domain.RawFormat{}, fmt.Errorf("cannot get absolute file path: %w", err)
+		}
+
+		rawPaths = append(rawPaths, pathToRaw)
+	}
+
+	return g.Notes.DetectRawFormat(rawPaths)
+}
+
+// Import implements domain.Importer
+func (g *GNotesImporter) Import(source string) (domain.Note, error) {
+	pathToRaw, err := g.Files.ParseAbsPath(source, gnotesRawFileName)
+	if err != nil {
+		return domain.Note{}, fmt.Errorf("cannot get absolute file path: %w", err)
+	}
+
+	// id is final directory name of provided directory path
+	id := g.Files.ParseBase(source)
+
+	// source folder is name of directory that contains the provided directory path
+	folder := g.Files.ParseBase(g.Files.ParseDir(source))
+
+	n, err := g.Notes.ParseFromRawFile(pathToRaw, folder, id)
+	if err != nil {
+		return domain.Note{}, fmt.Errorf("cannot parse note from file %s: %w", source, err)
+	}
+
+	attachments, err := g.Notes.ParseAttachmentsFromDir(source, folder, id, gnotesRawFileName)
+	if err != nil {
+		return domain.Note{}, fmt.Errorf("cannot parse attachments from directory %s: %w", source, err)
+	}
+
+	n.Attachments = append(attachments, n.Attachments...)
+
+	return n, nil
+}
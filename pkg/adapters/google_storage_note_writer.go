@@ -16,3 +16,10 @@ func (g *GoogleStorageNoteWriter) Write(n domain.Note) error {
 	log.Printf("google storage stub: note %s", n.Filename())
 	return nil
 }
+
+// WriteIndex implements domain.IndexWriter
+func (g *GoogleStorageNoteWriter) WriteIndex(parentDir string, idx domain.NoteIndex) error {
+	// TODO: implement me
+	log.Printf("google storage stub: index %s of %d notes", idx.Name, len(idx.Notes))
+	return nil
+}
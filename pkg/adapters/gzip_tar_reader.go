@@ -0,0 +1,153 @@
+package adapters
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"reorg/pkg/domain"
+	"sort"
+	"sync"
+)
+
+// gzipTarCacheSize defines the maximum number of bytes of decompressed file contents that are held,
+// so that files read slightly out of order do not require the archive to be decompressed again
+const gzipTarCacheSize = 64 << 20
+
+// gzipTarFile represents the position of a file's contents within a decompressed tar archive
+type gzipTarFile struct {
+	offset int64
+	size   int64
+}
+
+// gzipTarReader reads the contents of files within a gzipped tar archive by decompressing it sequentially,
+// holding the contents of recently decompressed files in memory
+//
+// Reading a file that precedes the current position, and is no longer held, decompresses the archive again
+// from the beginning.
+type gzipTarReader struct {
+	mux    sync.Mutex
+	file   domain.File      // gzipped tar archive
+	gz     *gzip.Reader     // decompressor of the archive, once a file has been read
+	pos    int64            // offset within the decompressed archive
+	files  []gzipTarFile    // files within the archive, ordered by offset
+	cache  map[int64][]byte // contents of recently decompressed files, by offset
+	order  []int64          // offsets of the cached files, oldest first
+	cached int64            // total size of the cached files
+}
+
+// newGzipTarReader returns a new gzipTarReader of the provided gzipped tar archive, whose items have been listed
+func newGzipTarReader(f domain.File, items []archiveItem) *gzipTarReader {
+	r := &gzipTarReader{file: f, cache: make(map[int64][]byte)}
+
+	for _, item := range items {
+		if !item.dir {
+			r.files = append(r.files, gzipTarFile{offset: item.offset, size: item.size})
+		}
+	}
+
+	sort.Slice(r.files, func(i, j int) bool {
+		return r.files[i].offset < r.files[j].offset
+	})
+
+	return r
+}
+
+// read returns the contents of the file at the provided offset within the decompressed archive
+//
+// Files that are passed over on the way to the provided offset are cached, since files are rarely read
+// in exactly the order that they are archived.
+func (r *gzipTarReader) read(offset, size int64) ([]byte, error) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	if b, ok := r.cache[offset]; ok {
+		return append([]byte(nil), b...), nil
+	}
+
+	if r.gz == nil || offset < r.pos {
+		if err := r.restart(); err != nil {
+			return nil, err
+		}
+	}
+
+	idx := sort.Search(len(r.files), func(i int) bool {
+		return r.files[i].offset >= r.pos
+	})
+
+	for ; idx < len(r.files) && r.files[idx].offset < offset; idx++ {
+		if _, err := r.readAt(r.files[idx].offset, r.files[idx].size); err != nil {
+			return nil, err
+		}
+	}
+
+	b, err := r.readAt(offset, size)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte(nil), b...), nil
+}
+
+// readAt decompresses and caches the contents of the file at the provided offset, which must not precede
+// the current position
+func (r *gzipTarReader) readAt(offset, size int64) ([]byte, error) {
+	if _, err := io.CopyN(ioutil.Discard, r.gz, offset-r.pos); err != nil {
+		return nil, fmt.Errorf("cannot decompress gzip archive: %w", err)
+	}
+	r.pos = offset
+
+	b := make([]byte, size)
+	if _, err := io.ReadFull(r.gz, b); err != nil {
+		return nil, fmt.Errorf("cannot decompress gzip archive: %w", err)
+	}
+	r.pos += size
+
+	r.store(offset, b)
+
+	return b, nil
+}
+
+// restart decompresses the archive again from its beginning
+func (r *gzipTarReader) restart() error {
+	if _, err := r.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	if r.gz == nil {
+		gz, err := gzip.NewReader(r.file)
+		if err != nil {
+			return fmt.Errorf("cannot read gzip archive: %w", err)
+		}
+		r.gz = gz
+	} else if err := r.gz.Reset(r.file); err != nil {
+		return fmt.Errorf("cannot read gzip archive: %w", err)
+	}
+
+	r.pos = 0
+
+	return nil
+}
+
+// store caches the provided contents of the file at the provided offset, evicting the oldest cached files
+// until the cache is within its maximum size
+func (r *gzipTarReader) store(offset int64, b []byte) {
+	if int64(len(b)) > gzipTarCacheSize {
+		return
+	}
+
+	if _, ok := r.cache[offset]; ok {
+		return
+	}
+
+	r.cache[offset] = b
+	r.order = append(r.order, offset)
+	r.cached += int64(len(b))
+
+	for r.cached > gzipTarCacheSize {
+		oldest := r.order[0]
+		r.order = r.order[1:]
+		r.cached -= int64(len(r.cache[oldest]))
+		delete(r.cache, oldest)
+	}
+}
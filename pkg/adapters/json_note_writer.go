@@ -4,31 +4,38 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
-	"os"
 	"reorg/pkg/domain"
-	"strings"
+	"sync"
 )
 
 // JSONNoteWriter writes a Note as a JSON file
 type JSONNoteWriter struct {
 	domain.NoteWriter
-	Files *domain.FileSystemService
+	Files    *domain.FileSystemService
+	EmbedRaw bool // embed the raw html source of each note, so that it can be re-cleaned later
+	mux      sync.Mutex
 }
 
 // Write implements domain.NoteWriter
 func (j *JSONNoteWriter) Write(n domain.Note) error {
 	parentDir := n.ParentDir
 
-	if n.Category != "" {
-		parentDir = strings.Join([]string{parentDir, n.Category}, string(os.PathSeparator))
+	if !j.EmbedRaw {
+		n.RawHTML = ""
 	}
 
 	// save note
-	filePath, err := generateAbsFilePath(j.Files, parentDir, n.Filename(), "json", n.Index)
+	filePath, err := generateAbsFilePath(j.Files, parentDir, n.RelPath(), "json")
 	if err != nil {
 		return fmt.Errorf("cannot generate file path: %w", err)
 	}
 
+	// note directory is created along with any directories that the note's path nests it within
+	noteDir := j.Files.ParseDir(filePath)
+	if err := createDirAll(&j.mux, j.Files, noteDir); err != nil {
+		return err
+	}
+
 	buf := bytes.NewBuffer(nil)
 	if err := json.NewEncoder(buf).Encode(&n); err != nil {
 		return fmt.Errorf("cannot parse json: %w", err)
@@ -38,5 +45,9 @@ func (j *JSONNoteWriter) Write(n domain.Note) error {
 		return fmt.Errorf("cannot write note with id %s: %w", n.ID, err)
 	}
 
+	if err := writeAttachments(&j.mux, j.Files, noteDir, n); err != nil {
+		return fmt.Errorf("cannot write attachments of note with id %s: %w", n.ID, err)
+	}
+
 	return nil
 }
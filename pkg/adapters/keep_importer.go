@@ -0,0 +1,153 @@
+package adapters
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reorg/pkg/domain"
+	"strings"
+	"time"
+)
+
+// keepDirName defines the name of the directory within a google takeout export that contains keep notes
+const keepDirName = "Keep"
+
+// takeoutDirName defines the name of the directory that a google takeout archive is extracted to
+const takeoutDirName = "Takeout"
+
+// keepIDHashLen defines the number of hex characters of the hash of a note's file name that is used within its id
+const keepIDHashLen = 8
+
+// keepNote represents a single note within a google keep takeout export
+type keepNote struct {
+	Title                   string `json:"title"`
+	TextContent             string `json:"textContent"`
+	TextContentHTML         string `json:"textContentHtml"`
+	IsTrashed               bool   `json:"isTrashed"`
+	IsArchived              bool   `json:"isArchived"`
+	IsPinned                bool   `json:"isPinned"`
+	UserEditedTimestampUsec int64  `json:"userEditedTimestampUsec"`
+	CreatedTimestampUsec    int64  `json:"createdTimestampUsec"`
+	ListContent             []struct {
+		Text      string `json:"text"`
+		IsChecked bool   `json:"isChecked"`
+	} `json:"listContent"`
+	Attachments []struct {
+		FilePath string `json:"filePath"`
+	} `json:"attachments"`
+}
+
+// KeepImporter imports Notes from a google keep takeout export, which contains a json file per note
+type KeepImporter struct {
+	domain.Importer
+	Files *domain.FileSystemService
+	Notes *domain.NoteService
+}
+
+// Sources implements domain.Importer
+//
+// Each source is the path to a note's json file, within either the provided directory or its nested Keep directory.
+func (k *KeepImporter) Sources(path string) ([]string, error) {
+	if err := k.Files.DirExists(path); err != nil {
+		return nil, fmt.Errorf("cannot find directory %s: %w", path, err)
+	}
+
+	for _, parts := range [][]string{{path, keepDirName}, {path, takeoutDirName, keepDirName}} {
+		keepPath, err := k.Files.ParseAbsPath(parts...)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse absolute path: %w", err)
+		}
+
+		if err := k.Files.DirExists(keepPath); err == nil {
+			path = keepPath
+			break
+		}
+	}
+
+	return k.Files.GetChildPaths(path, &domain.IsNotDir{}, &domain.IsJSON{})
+}
+
+// Import implements domain.Importer
+func (k *KeepImporter) Import(source string) (domain.Note, error) {
+	b, err := k.Files.ReadFile(source)
+	if err != nil {
+		return domain.Note{}, fmt.Errorf("cannot read file %s: %w", source, err)
+	}
+
+	var kn keepNote
+	if err := json.Unmarshal(b, &kn); err != nil {
+		return domain.Note{}, fmt.Errorf("cannot json decode file %s as keep note: %w", source, err)
+	}
+
+	id := keepNoteID(strings.TrimSuffix(k.Files.ParseBase(source), ".json"))
+
+	e := domain.ExportedNote{
+		ID:           id,
+		OriginalPath: source,
+		SourceFolder: keepDirName,
+		Title:        kn.Title,
+		Body:         kn.TextContent,
+		ModifiedAt:   usecToTime(kn.UserEditedTimestampUsec),
+		CreatedAt:    usecToTime(kn.CreatedTimestampUsec),
+		Archived:     kn.IsArchived,
+		Pinned:       kn.IsPinned,
+	}
+
+	if kn.IsTrashed {
+		e.SourceFolder = "Trash"
+	}
+
+	if kn.TextContentHTML != "" {
+		e.Body, e.HTML = kn.TextContentHTML, true
+	}
+
+	// older exports only record when a note was last edited, so this is not worth a warning
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = e.ModifiedAt
+	}
+
+	for _, item := range kn.ListContent {
+		e.Checklist = append(e.Checklist, domain.ChecklistItem{Text: strings.TrimSpace(item.Text), Done: item.IsChecked})
+	}
+
+	for _, a := range kn.Attachments {
+		p, err := k.Files.ParseAbsPath(k.Files.ParseDir(source), a.FilePath)
+		if err != nil {
+			return domain.Note{}, fmt.Errorf("cannot parse absolute path: %w", err)
+		}
+
+		attachment, err := k.Notes.ParseAttachmentFromFile(p, e.SourceFolder, id)
+		if err != nil {
+			return domain.Note{}, fmt.Errorf("cannot parse attachment %s: %w", a.FilePath, err)
+		}
+
+		e.Attachments = append(e.Attachments, attachment)
+	}
+
+	return k.Notes.ParseFromExportedNote(e)
+}
+
+// keepNoteID returns the id of the keep note with the provided file name, excluding its extension
+//
+// The id is the slug of the name, qualified by a short hash of the name if the slug differs from it,
+// so that notes whose file names share a slug do not share an id.
+func keepNoteID(name string) string {
+	id := domain.Slugify(name)
+	if id == name {
+		return id
+	}
+
+	sum := sha1.Sum([]byte(name))
+	return fmt.Sprintf("%s-%s", id, hex.EncodeToString(sum[:])[:keepIDHashLen])
+}
+
+// usecToTime returns the time represented by the provided number of microseconds since the unix epoch,
+// or the zero time if not positive
+func usecToTime(usec int64) time.Time {
+	if usec <= 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(0, usec*int64(time.Microsecond))
+}
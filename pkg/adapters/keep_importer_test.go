@@ -0,0 +1,128 @@
+package adapters
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"reorg/pkg/domain"
+	"testing"
+	"time"
+)
+
+// writeFixtures writes the provided files, by path relative to a temporary directory, returning the directory's path
+func writeFixtures(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	return dir
+}
+
+func TestKeepImporter(t *testing.T) {
+	dir := writeFixtures(t, map[string]string{
+		"Takeout/Keep/Shopping.json": `{
+			"title": "Shopping",
+			"textContent": "for the weekend",
+			"isPinned": true,
+			"createdTimestampUsec": 1616666400000000,
+			"userEditedTimestampUsec": 1616754600000000,
+			"listContent": [{"text": " eggs ", "isChecked": true}, {"text": "milk", "isChecked": false}]
+		}`,
+		"Takeout/Keep/Budget.json": `{
+			"title": "",
+			"textContent": "Q1 figures",
+			"textContentHtml": "<p>Q1 <b>figures</b></p>",
+			"isTrashed": true,
+			"userEditedTimestampUsec": 1616754600000000
+		}`,
+		"Takeout/Keep/Labels.txt": `not a note`,
+	})
+
+	fs := &OsFileSystem{}
+	im := &KeepImporter{
+		Files: domain.NewFileSystemService(fs),
+		Notes: domain.NewNoteService(fs),
+	}
+
+	sources, err := im.Sources(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	keepDir := filepath.Join(dir, "Takeout", "Keep")
+	if len(sources) != 2 {
+		t.Fatalf("got sources %q, want the two json files within %s", sources, keepDir)
+	}
+
+	shopping, err := im.Import(filepath.Join(keepDir, "Shopping.json"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if shopping.ID != keepNoteID("Shopping") || shopping.Title != "Shopping" || shopping.SourceFolder != "Keep" || !shopping.Pinned {
+		t.Errorf("got note %+v, want id of Shopping.json titled Shopping in folder Keep, pinned", shopping)
+	}
+
+	// plain text content is used as is
+	if shopping.Content != "for the weekend\n" {
+		t.Errorf("got content %q, want %q", shopping.Content, "for the weekend\n")
+	}
+
+	wantItems := []domain.ChecklistItem{{Text: "eggs", Done: true}, {Text: "milk"}}
+	if !reflect.DeepEqual(shopping.Checklist, wantItems) {
+		t.Errorf("got checklist %+v, want %+v", shopping.Checklist, wantItems)
+	}
+
+	if want := time.Unix(1616666400, 0); !shopping.CreatedAt.Equal(want) {
+		t.Errorf("got created %s, want %s", shopping.CreatedAt, want)
+	}
+
+	budget, err := im.Import(filepath.Join(keepDir, "Budget.json"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if budget.SourceFolder != "Trash" {
+		t.Errorf("got folder %q, want %q", budget.SourceFolder, "Trash")
+	}
+
+	// html content takes precedence over plain text content
+	if budget.Content != "Q1 figures\n" || budget.Title != "Q1 figures" {
+		t.Errorf("got title %q and content %q, want title inferred from html content", budget.Title, budget.Content)
+	}
+
+	// created falls back to modified without a warning
+	if want := time.Unix(1616754600, 0); !budget.CreatedAt.Equal(want) || !budget.ModifiedAt.Equal(want) {
+		t.Errorf("got timestamps %s and %s, want both %s", budget.CreatedAt, budget.ModifiedAt, want)
+	}
+
+	if len(budget.Warnings) != 0 {
+		t.Errorf("got warnings %q, want none", budget.Warnings)
+	}
+}
+
+func TestKeepNoteID(t *testing.T) {
+	if got := keepNoteID("shopping"); got != "shopping" {
+		t.Errorf("got %q, want %q", got, "shopping")
+	}
+
+	// names that share a slug do not share an id
+	a, b := keepNoteID("My Note"), keepNoteID("my-note")
+	if a == b {
+		t.Errorf("got id %q for both names, want distinct ids", a)
+	}
+
+	if want := "my-note-"; len(a) != len(want)+keepIDHashLen || a[:len(want)] != want {
+		t.Errorf("got %q, want slug %q qualified by a hash", a, want)
+	}
+}
@@ -20,3 +20,8 @@ func (o *OsFileInfo) IsDir() bool {
 func (o *OsFileInfo) Name() string {
 	return o.fi.Name()
 }
+
+// Size implements FileInfo.Size()
+func (o *OsFileInfo) Size() int64 {
+	return o.fi.Size()
+}
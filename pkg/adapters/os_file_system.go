@@ -19,6 +19,16 @@ func (o *OsFileSystem) ReadFile(path string) ([]byte, error) {
 	return ioutil.ReadFile(path)
 }
 
+// Open implements FileSystem.Open()
+func (o *OsFileSystem) Open(path string) (domain.File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
 // ReadFile implements FileSystem.ReadFile()
 func (o *OsFileSystem) WriteFile(path string, data []byte, perm uint32) error {
 	return ioutil.WriteFile(path, data, os.FileMode(perm))
@@ -85,11 +95,21 @@ func (o *OsFileSystem) Mkdir(path string, perm uint32) error {
 	return os.Mkdir(path, os.FileMode(perm))
 }
 
+// MkdirAll implements FileSystem.MkdirAll()
+func (o *OsFileSystem) MkdirAll(path string, perm uint32) error {
+	return os.MkdirAll(path, os.FileMode(perm))
+}
+
 // RemoveAll implements FileSystem.RemoveAll()
 func (o *OsFileSystem) RemoveAll(path string) error {
 	return os.RemoveAll(path)
 }
 
+// Rename implements FileSystem.Rename()
+func (o *OsFileSystem) Rename(oldPath, newPath string) error {
+	return os.Rename(oldPath, newPath)
+}
+
 // Abs implements FileSystem.Abs()
 func (o *OsFileSystem) Abs(pathParts ...string) (string, error) {
 	joined := strings.Join(pathParts, string(os.PathSeparator))
@@ -0,0 +1,149 @@
+package adapters
+
+import (
+	"encoding/json"
+	"fmt"
+	"reorg/pkg/domain"
+	"strings"
+	"time"
+)
+
+// simplenoteFileName defines the filename of the notes within a simplenote export
+const simplenoteFileName = "notes.json"
+
+// simplenoteSourceDirName defines the name of the directory within a simplenote export that contains its notes
+const simplenoteSourceDirName = "source"
+
+// simplenoteExport represents the notes within a simplenote export
+type simplenoteExport struct {
+	ActiveNotes  []simplenoteNote `json:"activeNotes"`
+	TrashedNotes []simplenoteNote `json:"trashedNotes"`
+}
+
+// simplenoteNote represents a single note within a simplenote export
+type simplenoteNote struct {
+	ID           string `json:"id"`
+	Content      string `json:"content"`
+	CreationDate string `json:"creationDate"`
+	LastModified string `json:"lastModified"`
+	Pinned       bool   `json:"pinned"`
+}
+
+// simplenoteEntry represents a note within a simplenote export alongside the folder it was exported from
+type simplenoteEntry struct {
+	path   string
+	folder string
+	note   simplenoteNote
+}
+
+// SimplenoteImporter imports Notes from a simplenote export, whose notes are all contained within a single json file
+type SimplenoteImporter struct {
+	domain.Importer
+	Files   *domain.FileSystemService
+	Notes   *domain.NoteService
+	entries map[string]simplenoteEntry // entries by source, populated by Sources
+}
+
+// Sources implements domain.Importer
+//
+// The provided path is either the notes file or a directory that contains it, directly or within its source directory.
+// Each source is the path to the notes file suffixed by the id of a note within it.
+func (s *SimplenoteImporter) Sources(path string) ([]string, error) {
+	p, err := s.findNotesFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := s.Files.ReadFile(p)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read file %s: %w", p, err)
+	}
+
+	var export simplenoteExport
+	if err := json.Unmarshal(b, &export); err != nil {
+		return nil, fmt.Errorf("cannot json decode file %s as simplenote export: %w", p, err)
+	}
+
+	s.entries = make(map[string]simplenoteEntry)
+
+	var sources []string
+
+	add := func(folder string, notes []simplenoteNote) {
+		for _, n := range notes {
+			source := fmt.Sprintf("%s#%s", p, n.ID)
+			s.entries[source] = simplenoteEntry{path: p, folder: folder, note: n}
+			sources = append(sources, source)
+		}
+	}
+
+	add("Simplenote", export.ActiveNotes)
+	add("Trash", export.TrashedNotes)
+
+	return sources, nil
+}
+
+// findNotesFile returns the path to the notes file of the simplenote export at the provided path
+func (s *SimplenoteImporter) findNotesFile(path string) (string, error) {
+	if err := s.Files.DirExists(path); err != nil {
+		return path, nil
+	}
+
+	for _, parts := range [][]string{{path, simplenoteFileName}, {path, simplenoteSourceDirName, simplenoteFileName}} {
+		p, err := s.Files.ParseAbsPath(parts...)
+		if err != nil {
+			return "", fmt.Errorf("cannot parse absolute path: %w", err)
+		}
+
+		if _, err := s.Files.ReadFile(p); err == nil {
+			return p, nil
+		}
+	}
+
+	return "", fmt.Errorf("cannot find %s in directory %s", simplenoteFileName, path)
+}
+
+// Import implements domain.Importer
+//
+// The first line of a note's content is its title.
+func (s *SimplenoteImporter) Import(source string) (domain.Note, error) {
+	entry, ok := s.entries[source]
+	if !ok {
+		return domain.Note{}, fmt.Errorf("cannot find note: %s", source)
+	}
+
+	content := strings.ReplaceAll(entry.note.Content, "\r\n", "\n")
+	lines := strings.SplitN(strings.TrimLeft(content, " \n"), "\n", 2)
+
+	e := domain.ExportedNote{
+		ID:           domain.Slugify(entry.note.ID),
+		OriginalPath: entry.path,
+		SourceFolder: entry.folder,
+		Title:        strings.TrimSpace(lines[0]),
+		Pinned:       entry.note.Pinned,
+	}
+
+	if len(lines) == 2 {
+		e.Body = lines[1]
+	}
+
+	var err error
+
+	if e.CreatedAt, err = parseSimplenoteTimestamp(entry.note.CreationDate); err != nil {
+		return domain.Note{}, fmt.Errorf("cannot parse created timestamp: %w", err)
+	}
+
+	if e.ModifiedAt, err = parseSimplenoteTimestamp(entry.note.LastModified); err != nil {
+		return domain.Note{}, fmt.Errorf("cannot parse modified timestamp: %w", err)
+	}
+
+	return s.Notes.ParseFromExportedNote(e)
+}
+
+// parseSimplenoteTimestamp parses the provided simplenote timestamp, returning the zero time if empty
+func parseSimplenoteTimestamp(raw string) (time.Time, error) {
+	if raw = strings.TrimSpace(raw); raw == "" {
+		return time.Time{}, nil
+	}
+
+	return time.Parse(time.RFC3339Nano, raw)
+}
@@ -0,0 +1,75 @@
+package adapters
+
+import (
+	"reorg/pkg/domain"
+	"testing"
+	"time"
+)
+
+func TestSimplenoteImporter(t *testing.T) {
+	dir := writeFixtures(t, map[string]string{
+		"source/notes.json": `{
+			"activeNotes": [
+				{"id": "Abc123", "content": "\r\n  Shopping \r\neggs\r\nmilk", "creationDate": "2021-03-25T10:00:00.000Z", "lastModified": "2021-03-26T11:30:00.000Z", "pinned": true},
+				{"id": "def456", "content": "Holiday", "creationDate": "25/03/2021", "lastModified": "2021-03-26T11:30:00.000Z"}
+			],
+			"trashedNotes": [
+				{"id": "ghi789", "content": "Budget\nQ1 figures", "creationDate": "2021-03-25T10:00:00.000Z", "lastModified": "2021-03-26T11:30:00.000Z"}
+			]
+		}`,
+	})
+
+	fs := &OsFileSystem{}
+	im := &SimplenoteImporter{
+		Files: domain.NewFileSystemService(fs),
+		Notes: domain.NewNoteService(fs),
+	}
+
+	sources, err := im.Sources(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(sources) != 3 {
+		t.Fatalf("got sources %q, want 3", sources)
+	}
+
+	shopping, err := im.Import(sources[0])
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// the first line is the title, once leading blank lines and crlf line endings are removed
+	if shopping.Title != "Shopping" || shopping.TitleInferred {
+		t.Errorf("got title %q, want %q", shopping.Title, "Shopping")
+	}
+
+	if shopping.Content != "eggs\nmilk\n" {
+		t.Errorf("got content %q, want %q", shopping.Content, "eggs\nmilk\n")
+	}
+
+	if shopping.ID != "abc123" || shopping.SourceFolder != "Simplenote" || !shopping.Pinned {
+		t.Errorf("got note %+v, want id abc123 in folder Simplenote, pinned", shopping)
+	}
+
+	if want := time.Date(2021, 3, 26, 11, 30, 0, 0, time.UTC); !shopping.ModifiedAt.Equal(want) {
+		t.Errorf("got modified %s, want %s", shopping.ModifiedAt, want)
+	}
+
+	if _, err := im.Import(sources[1]); err == nil {
+		t.Error("got no error for a note with an invalid created timestamp, want error")
+	}
+
+	budget, err := im.Import(sources[2])
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if budget.SourceFolder != "Trash" || budget.Title != "Budget" || budget.Content != "Q1 figures\n" {
+		t.Errorf("got note %+v, want Budget in folder Trash", budget)
+	}
+
+	if _, err := im.Import(dir + "#unknown"); err == nil {
+		t.Error("got no error for an unknown source, want error")
+	}
+}
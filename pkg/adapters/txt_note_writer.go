@@ -3,6 +3,7 @@ package adapters
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"reorg/pkg/domain"
 	"strings"
 	"sync"
@@ -13,86 +14,112 @@ type TxtNoteWriter struct {
 	domain.NoteWriter
 	SubDir string // represents sub-directory to write note to
 	Files  *domain.FileSystemService
-	mux    *sync.Mutex
+	mux    sync.Mutex
 }
 
 // Write implements domain.NoteWriter
 func (t *TxtNoteWriter) Write(n domain.Note) error {
-	if t.mux == nil {
-		t.mux = &sync.Mutex{}
-	}
-
 	parentDir := n.ParentDir
 
-	var err error
-
 	if t.SubDir != "" {
-		parentDir, err = joinAndCreateDir(t.mux, t.Files, []string{parentDir, t.SubDir})
-		if err != nil {
-			return err
-		}
+		parentDir = strings.Join([]string{parentDir, t.SubDir}, string(os.PathSeparator))
 	}
 
-	if n.Category != "" {
-		parentDir, err = joinAndCreateDir(t.mux, t.Files, []string{parentDir, n.Category})
-		if err != nil {
-			return err
-		}
+	// save note
+	filePath, err := generateAbsFilePath(t.Files, parentDir, n.RelPath(), noteExt(n))
+	if err != nil {
+		return fmt.Errorf("cannot generate file path: %w", err)
 	}
 
-	// create parent directory if it doesn't already exist
-	if err := createDir(t.mux, t.Files, parentDir); err != nil {
+	// note directory is created along with any directories that the note's path nests it within
+	noteDir := t.Files.ParseDir(filePath)
+	if err := createDirAll(&t.mux, t.Files, noteDir); err != nil {
 		return err
 	}
 
-	// save note
-	filePath, err := generateAbsFilePath(t.Files, parentDir, n.Filename(), "txt", n.Index)
+	content := []byte(renderContent(n))
+
+	if err := t.Files.WriteFile(filePath, content, 0644); err != nil {
+		return fmt.Errorf("cannot write note with id %s: %w", n.ID, err)
+	}
+
+	if err := writeAttachments(&t.mux, t.Files, noteDir, n); err != nil {
+		return fmt.Errorf("cannot write attachments of note with id %s: %w", n.ID, err)
+	}
+
+	return nil
+}
+
+// WriteIndex implements domain.IndexWriter
+func (t *TxtNoteWriter) WriteIndex(parentDir string, idx domain.NoteIndex) error {
+	if t.SubDir != "" {
+		parentDir = strings.Join([]string{parentDir, t.SubDir}, string(os.PathSeparator))
+	}
+
+	filePath, err := generateAbsFilePath(t.Files, parentDir, idx.Name, "txt")
 	if err != nil {
 		return fmt.Errorf("cannot generate file path: %w", err)
 	}
 
-	content := []byte(n.Content)
+	if err := createDirAll(&t.mux, t.Files, t.Files.ParseDir(filePath)); err != nil {
+		return err
+	}
 
-	if err := t.Files.WriteFile(filePath, content, 0644); err != nil {
-		return fmt.Errorf("cannot write note with id %s: %w", n.ID, err)
+	// list the path of each note relative to the parent directory, one per line
+	var sb strings.Builder
+	for _, n := range idx.Notes {
+		sb.WriteString(fmt.Sprintf("%s.%s\n", n.RelPath(), noteExt(n)))
+	}
+
+	if err := t.Files.WriteFile(filePath, []byte(sb.String()), 0644); err != nil {
+		return fmt.Errorf("cannot write index %s: %w", idx.Name, err)
 	}
 
 	return nil
 }
 
-// joinAndCreate joins the provided file parts and attempts to create the path as a directory
-func joinAndCreateDir(m *sync.Mutex, f *domain.FileSystemService, parts []string) (string, error) {
-	path := strings.Join(parts, string(os.PathSeparator))
-
-	if err := createDir(m, f, path); err != nil {
-		return "", err
+// noteExt returns the file extension of the provided Note, according to its content mode
+func noteExt(n domain.Note) string {
+	if n.ContentMode == domain.MarkdownContent {
+		return "md"
 	}
 
-	return path, nil
+	return "txt"
 }
 
-// createDir attempts to create the provided path as a directory if it doesn't exist
-func createDir(m *sync.Mutex, f *domain.FileSystemService, path string) error {
-	m.Lock()
-	defer m.Unlock()
+// renderContent renders the provided Note's content followed by any checklist items that are not rendered within it
+func renderContent(n domain.Note) string {
+	detached := n.DetachedChecklist()
+	if len(detached) == 0 {
+		return n.Content
+	}
 
-	if err := f.DirExists(path); err != nil {
-		if err := f.MakeDir(path); err != nil {
-			return fmt.Errorf("cannot make directory %s: %w", path, err)
+	var lines []string
+	for _, item := range detached {
+		line := item.String()
+		if n.ContentMode == domain.MarkdownContent {
+			// render as task list
+			line = "- " + line
 		}
+		lines = append(lines, line)
 	}
 
-	return nil
+	checklist := strings.Join(lines, "\n") + "\n"
+	if n.Content == "" {
+		return checklist
+	}
+
+	return fmt.Sprintf("%s\n%s", n.Content, checklist)
 }
 
-// generateAbsFilePath generates a file path from the provided arguments
-func generateAbsFilePath(f *domain.FileSystemService, dir, name, ext string, i int) (string, error) {
-	var suffix string
-	if i > 0 {
-		suffix = fmt.Sprintf("_%d", i)
+// generateAbsFilePath generates a file path from the provided arguments, where name may contain forward slashes
+// but must not lead outside of dir
+func generateAbsFilePath(f *domain.FileSystemService, dir, name, ext string) (string, error) {
+	if err := domain.ValidatePath(name); err != nil {
+		return "", fmt.Errorf("unsafe path %q: %w", name, err)
 	}
 
-	fileNameWithExt := fmt.Sprintf("%s%s.%s", name, suffix, ext)
+	fileNameWithExt := filepath.FromSlash(fmt.Sprintf("%s.%s", name, ext))
 
 	absPath, err := f.ParseAbsPath(dir, fileNameWithExt)
 	if err != nil {
@@ -1,11 +1,9 @@
 package command
 
 import (
-	"bufio"
 	"errors"
 	"fmt"
 	"log"
-	"os"
 	"reorg/pkg/domain"
 	"strings"
 )
@@ -16,15 +14,22 @@ const abridgedLen = 5
 // defaultCategory defines the category to use when user input is empty
 const defaultCategory = "_none"
 
+// setFlagPrefix and clearFlagPrefix define the prefixes that set or clear a note flag when entered alongside a category
+const (
+	setFlagPrefix   = "+"
+	clearFlagPrefix = "-"
+)
+
 // manifestFileName defines the filename whose contents represent a Notes manifest
 const manifestFileName = "manifest.json"
 
 // Categorise represents our categorise command
 type Categorise struct {
 	runner
-	InPath string
-	Files  *domain.FileSystemService
-	Notes  *domain.NoteService
+	InPath          string
+	FolderAsDefault bool // use a note's source folder as its category when user input is empty
+	Files           *domain.FileSystemService
+	Notes           *domain.NoteService
 }
 
 // Run implements Runner
@@ -50,7 +55,7 @@ func (c *Categorise) Run() error {
 		c.InPath,
 		&domain.IsNotDir{},
 		&domain.IsJSON{},
-		&domain.IsNotName{BaseNames: []string{manifestFileName}},
+		&domain.IsNotName{BaseNames: []string{manifestFileName, reportFileName}},
 	)
 	if err != nil {
 		return fmt.Errorf("validation error: %w", err)
@@ -67,6 +72,16 @@ func (c *Categorise) Run() error {
 		return fmt.Errorf("cannot parse notes: %w", err)
 	}
 
+	// notes cleaned before filenames were disambiguated may share a filename
+	notes, err = c.Notes.AssignFilenames(notes)
+	if err != nil {
+		return fmt.Errorf("cannot assign filenames: %w", err)
+	}
+
+	log.Println("extracting keywords...")
+
+	notes = c.Notes.ExtractKeywords(notes)
+
 	log.Println("parsing manifest from file...")
 
 	manifestPath, err := c.Files.ParseAbsPath(c.InPath, manifestFileName)
@@ -116,7 +131,20 @@ func (c *Categorise) validate() error {
 // requestCategories requests categories for each of the provided Notes in turn
 func (c *Categorise) requestCategories(notes []domain.Note, manifest domain.NoteManifest) error {
 	for _, n := range notes {
-		n.Category = requestCategory(n, true)
+		defaultCat := defaultCategory
+		if c.FolderAsDefault && n.SourceFolder != "" && !domain.IsReservedPath(n.SourceFolder) {
+			defaultCat = n.SourceFolder
+		}
+
+		// reserved categories are requested again
+		for {
+			err := applyCategoryInput(&n, requestCategory(n, defaultCat, true), defaultCat)
+			if err == nil {
+				break
+			}
+
+			fmt.Printf("%s, try another\n", err)
+		}
 
 		if err := manifest.Set(n); err != nil {
 			return fmt.Errorf("cannot set note on manifest: %w", err)
@@ -130,8 +158,38 @@ func (c *Categorise) requestCategories(notes []domain.Note, manifest domain.Note
 	return nil
 }
 
+// printMetadata outputs the keywords, links, email addresses, phone numbers, hashtags and flags of the provided Note,
+// where present
+func printMetadata(n domain.Note) {
+	hashtags := make([]string, 0, len(n.Hashtags))
+	for _, h := range n.Hashtags {
+		hashtags = append(hashtags, "#"+h)
+	}
+
+	var flags []string
+	for _, f := range n.Flags() {
+		flags = append(flags, string(f))
+	}
+
+	for _, field := range []struct {
+		label  string
+		values []string
+	}{
+		{label: "keywords", values: n.Keywords},
+		{label: "links", values: n.Links},
+		{label: "emails", values: n.Emails},
+		{label: "phones", values: n.Phones},
+		{label: "hashtags", values: hashtags},
+		{label: "flags", values: flags},
+	} {
+		if len(field.values) > 0 {
+			fmt.Printf("  %s: %s\n", field.label, strings.Join(field.values, ", "))
+		}
+	}
+}
+
 // requestCategory outputs the provided Note to console and returns the subsequent user input
-func requestCategory(n domain.Note, abridged bool) string {
+func requestCategory(n domain.Note, defaultCat string, abridged bool) string {
 	content := n.Content
 	if abridged == true {
 		lines := strings.Split(content, "\n")
@@ -140,18 +198,77 @@ func requestCategory(n domain.Note, abridged bool) string {
 		}
 	}
 
-	fmt.Printf("%s %s:\n%s\n", n.Timestamp.Format("2006-01-02"), n.Title, content)
-	fmt.Print("> category? [type `f` for full] ")
+	var folder string
+	if n.SourceFolder != "" {
+		folder = fmt.Sprintf(" [folder: %s]", n.SourceFolder)
+	}
+
+	title := n.Title
+	if n.TitleInferred {
+		title = fmt.Sprintf("%s (inferred)", title)
+	}
 
-	s := bufio.NewScanner(os.Stdin)
-	s.Scan()
-	inp := s.Text()
-	switch inp {
-	case "f":
+	fmt.Printf("%s %s%s:\n%s\n", n.Timestamp().Format("2006-01-02"), title, folder, content)
+	printMetadata(n)
+	fmt.Printf(
+		"> category? [default `%s`, add tags with `%stag`, set or clear flags with `%sstarred` or `%sstarred`, type `f` for full] ",
+		defaultCat, domain.TagPrefix, setFlagPrefix, clearFlagPrefix,
+	)
+
+	stdin.Scan()
+	inp := stdin.Text()
+	if inp == "f" {
 		// render full content
-		return requestCategory(n, false)
-	case "":
-		inp = defaultCategory
+		return requestCategory(n, defaultCat, false)
 	}
 	return inp
 }
+
+// applyCategoryInput sets the category, tags and flags of the provided Note from the provided user input,
+// using the provided default category if no category is input
+//
+// Each tag is a word beginning with the tag prefix, and each flag is the name of a flag beginning with the set or clear
+// flag prefix (e.g. `travel #receipts +starred -archived`). Flags that are not input are retained.
+// The note is left unchanged if the category is reserved for indexes.
+func applyCategoryInput(n *domain.Note, inp, defaultCat string) error {
+	var words, tags []string
+	flags := make(map[domain.NoteFlag]bool)
+
+	for _, word := range strings.Fields(inp) {
+		if strings.HasPrefix(word, domain.TagPrefix) {
+			tags = append(tags, word)
+			continue
+		}
+
+		if f, err := domain.ParseNoteFlag(strings.ToLower(word[1:])); err == nil {
+			switch word[:1] {
+			case setFlagPrefix:
+				flags[f] = true
+				continue
+			case clearFlagPrefix:
+				flags[f] = false
+				continue
+			}
+		}
+
+		words = append(words, word)
+	}
+
+	category := strings.Join(words, " ")
+	if category == "" {
+		category = defaultCat
+	}
+
+	if domain.IsReservedPath(category) {
+		return fmt.Errorf("category %s is reserved", category)
+	}
+
+	n.Category = category
+	n.Tags = domain.ParseTags(tags...)
+
+	for f, v := range flags {
+		n.SetFlag(f, v)
+	}
+
+	return nil
+}
@@ -1,22 +1,74 @@
 package command
 
 import (
+	"bytes"
 	"context"
+	"encoding/gob"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
 	"reorg/pkg/domain"
-	"time"
+	"strings"
 )
 
+// quarantineDirName defines the output directory that raw note directories which fail to parse are copied to
+const quarantineDirName = "_quarantine"
+
+// spoolSuffix defines the suffix of the directory that imported notes are held in until they are written
+const spoolSuffix = ".spool"
+
+// stagingSuffix defines the suffix of the directory that notes are written to before they replace the output directory
+const stagingSuffix = ".partial"
+
+// reportFileName defines the filename whose contents represent the failures and warnings of a tolerant clean
+const reportFileName = "clean_report.json"
+
+// cleanReport represents the failures and warnings encountered by a tolerant clean
+type cleanReport struct {
+	Failures []cleanFailure `json:"failures"`
+	Warnings []cleanWarning `json:"warnings"`
+}
+
+// cleanOutcome represents the outcome of parsing and writing notes
+type cleanOutcome struct {
+	written     int
+	attachments int
+	failures    []cleanFailure
+	warnings    []cleanWarning
+}
+
+// cleanFailure represents a raw note directory that failed to parse
+type cleanFailure struct {
+	Path   string `json:"path"`   // source of the note, such as a full-qualified path to raw note directory
+	Reason string `json:"reason"` // reason that the note failed to parse
+}
+
+// cleanWarning represents a recoverable problem encountered while parsing a note
+type cleanWarning struct {
+	ID      string `json:"id"`      // id of the note
+	Path    string `json:"path"`    // full-qualified path to raw note source file
+	Warning string `json:"warning"` // description of the problem
+}
+
 // Clean represents our clean command
 type Clean struct {
 	runner
-	InPath  string
-	OutPath string
-	Writer  domain.NoteWriter
-	Files   *domain.FileSystemService
-	Notes   *domain.NoteService
+	InPath   string
+	OutPath  string
+	Importer domain.Importer        // importer of notes from the export at InPath
+	DateBy   domain.TimestampKind   // timestamp that cleaned notes are dated by
+	Tolerant bool                   // quarantine notes that fail to parse rather than aborting
+	Workers  int                    // number of notes to parse concurrently
+	Rules    *domain.RuleSet        // user-defined rules applied by the note service, whose matches are reported
+	Paths    []*domain.PathTemplate // candidate templates of note paths, in order of preference (filename by default)
+	Flat     bool                   // note paths must not be nested within directories
+	Writer   domain.NoteWriter
+	Files    *domain.FileSystemService
+	Notes    *domain.NoteService
+
+	stagingPath string // directory that notes are written to, which replaces the output directory once complete
+	spoolPath   string // directory that imported notes are held in until they are written
 }
 
 // Run implements Runner
@@ -27,7 +79,7 @@ func (c *Clean) Run() error {
 
 	var err error
 
-	c.InPath, err = c.Files.ParseAbsPath(c.InPath, "Other")
+	c.InPath, err = c.Files.ParseAbsPath(c.InPath)
 	if err != nil {
 		return fmt.Errorf("cannot parse absolute path %s: %w", c.InPath, err)
 	}
@@ -37,37 +89,86 @@ func (c *Clean) Run() error {
 		return fmt.Errorf("cannot parse absolute path %s: %w", c.OutPath, err)
 	}
 
-	if err := c.Files.DirExists(c.InPath); err != nil {
-		return fmt.Errorf("cannot find directory %s: %w", c.InPath, err)
+	if n := c.Rules.Len(); n > 0 {
+		log.Printf("applying %d user-defined rules", n)
 	}
 
-	log.Printf("scanning directory: %s", c.InPath)
+	log.Printf("scanning export: %s", c.InPath)
 
-	dirs, err := c.Files.GetChildPaths(c.InPath, &domain.IsDir{})
+	sources, err := c.Importer.Sources(c.InPath)
 	if err != nil {
-		return fmt.Errorf("validation error: %w", err)
+		return fmt.Errorf("cannot find notes in export %s: %w", c.InPath, err)
 	}
 
-	if len(dirs) == 0 {
-		return fmt.Errorf("no directories found in parent: %s", c.InPath)
+	if len(sources) == 0 {
+		return fmt.Errorf("no notes found in export: %s", c.InPath)
 	}
 
-	log.Printf("%d directories to search for note files", len(dirs))
+	log.Printf("%d notes to import", len(sources))
+
+	// notes are written to a staging directory first, so that the existing output is only reset once every note
+	// has been parsed and written successfully
+	c.stagingPath = c.OutPath + stagingSuffix
+	c.spoolPath = c.OutPath + spoolSuffix
+
+	if err := c.Files.DirExists(c.stagingPath); err == nil {
+		log.Printf("staging directory from a previous run will be removed: %s", c.stagingPath)
+	}
 
 	if !cont() {
 		return errors.New("aborted")
 	}
 
-	log.Println("parsing notes...")
+	if d, ok := c.Importer.(domain.RawFormatDetector); ok {
+		log.Println("detecting raw file format...")
 
-	notes, err := c.parseNotesFromRawDirs(dirs)
+		format, err := d.DetectRawFormat(sources)
+		if err != nil {
+			return err
+		}
+
+		log.Printf("detected header labels: %s", format.Locale)
+		log.Printf("detected timestamp layout: %s", format.Layout)
+	}
+
+	if err := c.Files.RemoveAll(c.stagingPath); err != nil {
+		return fmt.Errorf("cannot remove directory %s: %w", c.stagingPath, err)
+	}
+
+	if err := c.Files.MakeDir(c.stagingPath); err != nil {
+		return fmt.Errorf("cannot create directory %s: %w", c.stagingPath, err)
+	}
+
+	// staging directory no longer exists once it has replaced the output directory
+	defer c.Files.RemoveAll(c.stagingPath)
+
+	log.Printf("parsing and writing notes using %d workers...", c.Workers)
+
+	out, err := c.parseAndWriteNotes(sources)
 	if err != nil {
 		return err
 	}
 
-	notes = enrichNotesWithParentDir(notes, c.OutPath)
+	log.Printf("%d notes parsed with %d attachments", out.written, out.attachments)
+
+	for _, rc := range c.Rules.Counts() {
+		log.Printf("rule %q matched %d times", rc.Name, rc.Matches)
+	}
+
+	if len(out.failures) > 0 {
+		log.Printf("WARNING: %d notes failed to parse", len(out.failures))
+	}
+
+	if c.Tolerant {
+		if err := c.quarantine(out.failures); err != nil {
+			return err
+		}
+
+		if err := c.writeReport(out.failures, out.warnings); err != nil {
+			return err
+		}
+	}
 
-	log.Printf("parsed %d notes\n", len(notes))
 	log.Printf("writing to directory: %s", c.OutPath)
 	log.Println("this will reset its existing contents")
 
@@ -75,25 +176,16 @@ func (c *Clean) Run() error {
 		return errors.New("aborted")
 	}
 
-	// clear output directory
+	// replace output directory
 	if err := c.Files.RemoveAll(c.OutPath); err != nil {
 		return fmt.Errorf("cannot remove directory %s: %w", c.OutPath, err)
 	}
 
-	// recreate output directory
-	if err := c.Files.MakeDir(c.OutPath); err != nil {
-		return fmt.Errorf("cannot create directory %s: %w", c.OutPath, err)
+	if err := c.Files.Rename(c.stagingPath, c.OutPath); err != nil {
+		return fmt.Errorf("cannot move directory %s to %s: %w", c.stagingPath, c.OutPath, err)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(5)*time.Second)
-	defer cancel()
-
-	n, err := c.Notes.WriteNotes(ctx, notes, c.Writer)
-	if err != nil {
-		return err
-	}
-
-	log.Printf("finished writing %d notes\n", n)
+	log.Printf("finished writing %d notes with %d attachments", out.written, out.attachments)
 
 	return nil
 }
@@ -108,6 +200,18 @@ func (c *Clean) validate() error {
 		return errors.New("output path is empty")
 	}
 
+	if c.Workers < 1 {
+		return errors.New("must provide at least one worker")
+	}
+
+	if c.DateBy == "" {
+		return errors.New("must provide a timestamp to date notes by")
+	}
+
+	if c.Importer == nil {
+		return errors.New("must provide a note importer")
+	}
+
 	if c.Writer == nil {
 		return errors.New("must provide a note writer")
 	}
@@ -115,38 +219,268 @@ func (c *Clean) validate() error {
 	return nil
 }
 
-// parseNotesFromRawDirs parses Notes from the provided raw directory paths
-func (c *Clean) parseNotesFromRawDirs(paths []string) ([]domain.Note, error) {
-	var notes []domain.Note
+// parseAndWriteNotes imports Notes concurrently from the provided sources and writes them using the writer,
+// in the same order as the provided sources
+//
+// If tolerant, sources that fail to import are returned as failures rather than aborting.
+// Every note is imported once, and is only written once all have been imported, so that every note that shares
+// a filename can be suffixed. If path templates are provided, notes are held in memory so that a template can be
+// chosen. Otherwise, each note's filename is reserved and the note is spooled to disk until it is written,
+// so that memory use does not grow with the size of the export.
+func (c *Clean) parseAndWriteNotes(sources []string) (cleanOutcome, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if len(c.Paths) == 0 {
+		if err := c.Files.RemoveAll(c.spoolPath); err != nil {
+			return cleanOutcome{}, fmt.Errorf("cannot remove directory %s: %w", c.spoolPath, err)
+		}
+
+		if err := c.Files.MakeDir(c.spoolPath); err != nil {
+			return cleanOutcome{}, fmt.Errorf("cannot create directory %s: %w", c.spoolPath, err)
+		}
+
+		defer c.Files.RemoveAll(c.spoolPath)
+	}
+
+	var out cleanOutcome
+	var filenames domain.FilenameSet
+	var parsed []domain.Note
+	var spooled []int // indexes of the sources whose notes have been spooled, in order
+
+	idx := -1
+	for r := range domain.StreamNotes(ctx, sources, c.Workers, c.importNote) {
+		idx++
+
+		if r.Err != nil {
+			if !c.Tolerant {
+				return cleanOutcome{}, r.Err
+			}
+
+			out.failures = append(out.failures, cleanFailure{Path: r.Source, Reason: r.Err.Error()})
+			continue
+		}
+
+		for _, w := range r.Note.Warnings {
+			out.warnings = append(out.warnings, cleanWarning{ID: r.Note.ID, Path: r.Note.OriginalPath, Warning: w})
+		}
+		out.attachments += len(r.Note.Attachments)
+
+		if len(c.Paths) > 0 {
+			parsed = append(parsed, r.Note)
+			continue
+		}
+
+		filenames.Reserve(r.Note)
+
+		if err := c.spoolNote(idx, r.Note); err != nil {
+			return cleanOutcome{}, err
+		}
+		spooled = append(spooled, idx)
+	}
+
+	var parseErr error
+
+	notes := make(chan domain.Note)
+	done := make(chan struct{})
 
-	for _, p := range paths {
-		pathToRaw, err := c.Files.ParseAbsPath(p, "content.html")
+	go func() {
+		defer close(done)
+		defer close(notes)
+
+		send := func(n domain.Note) bool {
+			select {
+			case notes <- n:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		if len(c.Paths) == 0 {
+			for _, idx := range spooled {
+				n, err := c.unspoolNote(idx)
+				if err == nil {
+					err = filenames.AssignReserved(&n)
+				}
+				if err != nil {
+					parseErr = err
+					cancel()
+					return
+				}
+
+				if !send(n) {
+					return
+				}
+			}
+			return
+		}
+
+		// templates may render filenames, which must be unique before paths are compared
+		parsed, err := c.Notes.AssignFilenames(parsed)
 		if err != nil {
-			return nil, fmt.Errorf("cannot get absolute file path: %w", err)
+			parseErr = err
+			cancel()
+			return
+		}
+
+		applied, pt, err := c.Notes.ApplyPathTemplates(parsed, c.Paths, c.Flat)
+		if err != nil {
+			parseErr = err
+			cancel()
+			return
+		}
+
+		log.Printf("writing notes using path template: %s", pt)
+
+		for _, n := range applied {
+			if !send(n) {
+				return
+			}
+		}
+	}()
+
+	written, err := c.Notes.WriteNoteStream(ctx, notes, c.Writer)
+
+	// stop reading notes if writing failed, then wait for reading to finish
+	cancel()
+	<-done
+
+	if parseErr != nil {
+		return cleanOutcome{}, parseErr
+	}
+
+	if err != nil {
+		return cleanOutcome{}, err
+	}
+
+	out.written = written
+
+	return out, nil
+}
+
+// spoolNote writes the provided Note, imported from the source at the provided index, to the spool directory
+//
+// Notes are gob encoded rather than json encoded, so that fields which are not stored, such as the data
+// of inline attachments, are retained.
+func (c *Clean) spoolNote(idx int, n domain.Note) error {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(n); err != nil {
+		return fmt.Errorf("cannot gob encode note with id %s: %w", n.ID, err)
+	}
+
+	p, err := c.Files.ParseAbsPath(c.spoolPath, fmt.Sprintf("%d.gob", idx))
+	if err != nil {
+		return fmt.Errorf("cannot parse spool path: %w", err)
+	}
+
+	if err := c.Files.WriteFile(p, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("cannot write file %s: %w", p, err)
+	}
+
+	return nil
+}
+
+// unspoolNote reads the Note imported from the source at the provided index from the spool directory
+func (c *Clean) unspoolNote(idx int) (domain.Note, error) {
+	p, err := c.Files.ParseAbsPath(c.spoolPath, fmt.Sprintf("%d.gob", idx))
+	if err != nil {
+		return domain.Note{}, fmt.Errorf("cannot parse spool path: %w", err)
+	}
+
+	b, err := c.Files.ReadFile(p)
+	if err != nil {
+		return domain.Note{}, fmt.Errorf("cannot read file %s: %w", p, err)
+	}
+
+	var n domain.Note
+	if err := gob.NewDecoder(bytes.NewReader(b)).Decode(&n); err != nil {
+		return domain.Note{}, fmt.Errorf("cannot gob decode file %s: %w", p, err)
+	}
+
+	return n, nil
+}
+
+// importNote imports a Note from the provided source
+func (c *Clean) importNote(source string) (domain.Note, error) {
+	n, err := c.Importer.Import(source)
+	if err != nil {
+		return domain.Note{}, err
+	}
+
+	// recoverable problems are only tolerated in tolerant mode
+	if len(n.Warnings) > 0 && !c.Tolerant {
+		return domain.Note{}, fmt.Errorf("cannot import note from %s: %s", source, strings.Join(n.Warnings, ", "))
+	}
+
+	n.ParentDir = c.stagingPath
+	n.DateBy = c.DateBy
+
+	return n, nil
+}
+
+// quarantine copies each of the provided failed raw directories to the quarantine directory, retaining their folders
+//
+// Failures whose sources are not directories are only reported.
+func (c *Clean) quarantine(failures []cleanFailure) error {
+	var count int
+
+	for _, f := range failures {
+		if err := c.Files.DirExists(f.Path); err != nil {
+			continue
 		}
 
-		// id is final directory name of provided directory path
-		id := c.Files.ParseBase(p)
+		folder := c.Files.ParseBase(c.Files.ParseDir(f.Path))
 
-		n, err := c.Notes.ParseFromRawFile(pathToRaw, id)
+		dst, err := c.Files.ParseAbsPath(c.stagingPath, quarantineDirName, folder, c.Files.ParseBase(f.Path))
 		if err != nil {
-			return nil, fmt.Errorf("cannot parse note from file %s: %w", p, err)
+			return fmt.Errorf("cannot parse quarantine path: %w", err)
 		}
 
-		notes = append(notes, n)
+		if err := c.Files.CopyDir(f.Path, dst); err != nil {
+			return fmt.Errorf("cannot quarantine directory %s: %w", f.Path, err)
+		}
+
+		count++
+	}
+
+	if count > 0 {
+		log.Printf("quarantined %d notes to directory: %s", count, quarantineDirName)
 	}
 
-	return notes, nil
+	return nil
 }
 
-// enrichNotesWithParentDir enriches the provided Notes with the provided parent directory value
-func enrichNotesWithParentDir(notes []domain.Note, parentDir string) []domain.Note {
-	var enriched []domain.Note
+// writeReport writes a report of the provided failures and warnings to the output directory
+func (c *Clean) writeReport(failures []cleanFailure, warnings []cleanWarning) error {
+	report := cleanReport{
+		Failures: failures,
+		Warnings: warnings,
+	}
 
-	for _, n := range notes {
-		n.ParentDir = parentDir
-		enriched = append(enriched, n)
+	if report.Failures == nil {
+		report.Failures = []cleanFailure{}
 	}
 
-	return enriched
+	if report.Warnings == nil {
+		report.Warnings = []cleanWarning{}
+	}
+
+	b, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("cannot json encode report: %w", err)
+	}
+
+	path, err := c.Files.ParseAbsPath(c.stagingPath, reportFileName)
+	if err != nil {
+		return fmt.Errorf("cannot parse report path: %w", err)
+	}
+
+	if err := c.Files.WriteFile(path, b, 0644); err != nil {
+		return fmt.Errorf("cannot write report %s: %w", path, err)
+	}
+
+	log.Printf("%d failures and %d warnings reported in file: %s", len(report.Failures), len(report.Warnings), reportFileName)
+
+	return nil
 }
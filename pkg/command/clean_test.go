@@ -0,0 +1,149 @@
+package command
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reorg/pkg/adapters"
+	"reorg/pkg/domain"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newClean returns a Clean of the export at the provided path to the provided output directory as txt files,
+// whose confirmations are all accepted
+func newClean(t *testing.T, inPath, outPath string, tolerant bool) *Clean {
+	stdin = bufio.NewScanner(strings.NewReader("Y\nY\n"))
+
+	tp, err := domain.NewTimestampParser(time.UTC, domain.DefaultTimestampLayouts)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	fs := &adapters.OsFileSystem{}
+	files := domain.NewFileSystemService(fs)
+	notes := domain.NewNoteService(fs, domain.WithTimestampParser(tp))
+
+	return &Clean{
+		InPath:   inPath,
+		OutPath:  outPath,
+		Importer: &adapters.GNotesImporter{Files: files, Notes: notes},
+		DateBy:   domain.CreatedTimestamp,
+		Tolerant: tolerant,
+		Workers:  2,
+		Writer:   &adapters.TxtNoteWriter{Files: files},
+		Files:    files,
+		Notes:    notes,
+	}
+}
+
+// listFiles returns the paths of the files within the provided directory, relative to it using forward slashes
+func listFiles(t *testing.T, dir string) []string {
+	var files []string
+
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, p)
+		files = append(files, filepath.ToSlash(rel))
+		return err
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	sort.Strings(files)
+	return files
+}
+
+func TestClean_Tolerant(t *testing.T) {
+	// the header of the last note has no created timestamp, which is a recoverable problem
+	noCreated := strings.Replace(rawNote("Holiday"), "Create Time: 25/03/2021 10:00<br>", "", 1)
+
+	dirs := map[string]string{
+		"Other/101": rawNote("Shopping"),
+		"Other/102": "<html><body><p>not a gnotes note</p></body></html>",
+		"Other/103": noCreated,
+	}
+
+	tt := []struct {
+		name      string
+		tolerant  bool
+		wantErr   bool
+		wantFiles []string
+	}{
+		{
+			name:     "malformed notes are quarantined and reported while the rest are written",
+			tolerant: true,
+			wantFiles: []string{
+				"2021-03-25_shopping.txt",
+				"2021-03-26_holiday.txt",
+				"_quarantine/Other/102/content.html",
+				"clean_report.json",
+			},
+		},
+		{
+			name:      "malformed notes abort the clean and leave the previous output untouched",
+			wantErr:   true,
+			wantFiles: []string{"previous.txt"},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			root := writeExport(t, dirs)
+			out := filepath.Join(t.TempDir(), "cleaned")
+
+			if err := os.MkdirAll(out, 0755); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if err := ioutil.WriteFile(filepath.Join(out, "previous.txt"), []byte("previous"), 0644); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			err := newClean(t, root, out, tc.tolerant).Run()
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("got error %v, want error %t", err, tc.wantErr)
+			}
+
+			got := listFiles(t, out)
+			if strings.Join(got, ",") != strings.Join(tc.wantFiles, ",") {
+				t.Errorf("got files %q, want %q", got, tc.wantFiles)
+			}
+
+			for _, suffix := range []string{stagingSuffix, spoolSuffix} {
+				if _, err := os.Stat(out + suffix); !os.IsNotExist(err) {
+					t.Errorf("got %s directory, want it removed", suffix)
+				}
+			}
+
+			if !tc.tolerant {
+				return
+			}
+
+			b, err := ioutil.ReadFile(filepath.Join(out, reportFileName))
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			var report cleanReport
+			if err := json.Unmarshal(b, &report); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if len(report.Failures) != 1 || report.Failures[0].Path != filepath.Join(root, "Other", "102") {
+				t.Errorf("got failures %+v, want a failure of Other/102", report.Failures)
+			}
+
+			if len(report.Warnings) != 1 || report.Warnings[0].ID != "103" {
+				t.Errorf("got warnings %+v, want a warning of note 103", report.Warnings)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package command
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"log"
 	"os"
@@ -13,23 +14,50 @@ func Run(r runner) {
 	log.Println("running...")
 
 	if err := r.Run(); err != nil {
+		// expected failures exit with their own status code rather than panicking
+		var ec exitCoder
+		if errors.As(err, &ec) {
+			Exit(err)
+		}
+
 		panic(err)
 	}
 
 	log.Println("complete!")
 }
 
+// Exit logs the provided error and exits with its status code if it is an expected failure, or 1 otherwise
+func Exit(err error) {
+	log.Println(err)
+
+	var ec exitCoder
+	if errors.As(err, &ec) {
+		os.Exit(ec.ExitCode())
+	}
+
+	os.Exit(1)
+}
+
 // runner defines the behaviour of a command runner
 type runner interface {
 	Run() error
 }
 
+// exitCoder defines an error that represents an expected failure, which exits with a specific status code
+type exitCoder interface {
+	error
+	ExitCode() int
+}
+
+// stdin reads the user's input, shared across prompts so that piped input buffered by one prompt
+// is not lost to the next
+var stdin = bufio.NewScanner(os.Stdin)
+
 // cont prompts the user for confirmation to continue
 func cont() bool {
 	fmt.Print("> continue? [Y/n] ")
 
-	s := bufio.NewScanner(os.Stdin)
-	if s.Scan(); s.Text() != "Y" {
+	if stdin.Scan(); stdin.Text() != "Y" {
 		return false
 	}
 
@@ -0,0 +1,362 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"reorg/pkg/domain"
+	"sort"
+	"strings"
+)
+
+// lintProblemKind defines the kind of a problem found within an export
+type lintProblemKind string
+
+const (
+	missingContentProblem    lintProblemKind = "missing_content"    // raw note directory has no content.html
+	unknownFormatProblem     lintProblemKind = "unknown_format"     // header labels or timestamp layout cannot be detected across the export
+	unknownHeaderProblem     lintProblemKind = "unknown_header"     // raw note header is not written with any known labels
+	invalidTimestampProblem  lintProblemKind = "invalid_timestamp"  // raw note timestamp cannot be located or parsed
+	parseErrorProblem        lintProblemKind = "parse_error"        // raw note fails to parse for any other reason
+	duplicateIDProblem       lintProblemKind = "duplicate_id"       // raw note directories share a gnotes id and the directory their attachments are written to
+	sharedIDProblem          lintProblemKind = "shared_id"          // raw note directories in different folders share a gnotes id (a warning)
+	duplicateFilenameProblem lintProblemKind = "duplicate_filename" // notes share a date and title, so their filenames are disambiguated (a warning)
+)
+
+// lintProblem represents a single problem found within an export
+type lintProblem struct {
+	Kind   lintProblemKind `json:"kind"`   // kind of problem
+	Path   string          `json:"path"`   // full-qualified path to the raw note directory or export that the problem concerns
+	Detail string          `json:"detail"` // description of the problem
+}
+
+// lintReport represents the outcome of linting an export
+type lintReport struct {
+	Path     string        `json:"path"`             // full-qualified path to the export
+	Notes    int           `json:"notes"`            // number of raw note directories found
+	Locale   string        `json:"locale,omitempty"` // detected locale of the header labels
+	Layout   string        `json:"layout,omitempty"` // detected layout of the raw timestamps
+	Problems []lintProblem `json:"problems"`         // problems found, in order of path
+	Warnings []lintProblem `json:"warnings"`         // problems found that cleaning resolves by itself, in order of path
+}
+
+// lintFailure represents an export that contains problems
+type lintFailure struct {
+	problems int
+}
+
+// Error implements error
+func (l lintFailure) Error() string {
+	return fmt.Sprintf("found %d problems", l.problems)
+}
+
+// ExitCode implements exitCoder
+func (l lintFailure) ExitCode() int {
+	return 1
+}
+
+// lintSetupFailure represents an export that cannot be linted at all, such as due to an invalid flag or a missing export
+type lintSetupFailure struct {
+	err error
+}
+
+// LintSetupFailure returns an expected failure that represents an export that cannot be linted due to the provided error
+func LintSetupFailure(err error) error {
+	return lintSetupFailure{err: err}
+}
+
+// Error implements error
+func (l lintSetupFailure) Error() string {
+	return fmt.Sprintf("cannot lint export: %s", l.err)
+}
+
+// Unwrap returns the underlying error
+func (l lintSetupFailure) Unwrap() error {
+	return l.err
+}
+
+// ExitCode implements exitCoder
+func (l lintSetupFailure) ExitCode() int {
+	return 2
+}
+
+// Lint represents our lint command, which validates a raw gnotes export without modifying anything
+type Lint struct {
+	runner
+	InPath   string
+	Importer domain.Importer      // importer of notes from the export at InPath
+	JSON     bool                 // output the report as json rather than a human summary
+	Out      io.Writer            // destination of the report
+	DateBy   domain.TimestampKind // timestamp that cleaned notes would be dated by
+	Workers  int                  // number of notes to parse concurrently
+	Files    *domain.FileSystemService
+}
+
+// Run implements Runner
+//
+// Any error other than problems found within the export means that the export cannot be linted at all.
+func (l *Lint) Run() error {
+	err := l.run()
+
+	var failure lintFailure
+	if err != nil && !errors.As(err, &failure) {
+		return LintSetupFailure(err)
+	}
+
+	return err
+}
+
+// run lints the export, returning a lintFailure if it contains problems
+func (l *Lint) run() error {
+	if err := l.validate(); err != nil {
+		return fmt.Errorf("validation error: %w", err)
+	}
+
+	var err error
+
+	l.InPath, err = l.Files.ParseAbsPath(l.InPath)
+	if err != nil {
+		return fmt.Errorf("cannot parse absolute path %s: %w", l.InPath, err)
+	}
+
+	if err := l.Files.DirExists(l.InPath); err != nil {
+		return fmt.Errorf("cannot find directory %s: %w", l.InPath, err)
+	}
+
+	log.Printf("scanning export: %s", l.InPath)
+
+	report := lintReport{Path: l.InPath, Problems: []lintProblem{}, Warnings: []lintProblem{}}
+
+	sources, err := l.Importer.Sources(l.InPath)
+	if err != nil {
+		return fmt.Errorf("cannot find notes in export %s: %w", l.InPath, err)
+	}
+
+	report.Notes = len(sources)
+
+	if d, ok := l.Importer.(domain.RawFormatDetector); ok && len(sources) > 0 {
+		log.Println("detecting raw file format...")
+
+		format, err := d.DetectRawFormat(sources)
+		if err != nil {
+			report.Problems = append(report.Problems, lintProblem{Kind: unknownFormatProblem, Path: l.InPath, Detail: err.Error()})
+		}
+		report.Locale, report.Layout = format.Locale, format.Layout
+	}
+
+	log.Printf("parsing %d notes using %d workers...", len(sources), l.Workers)
+
+	l.lintNotes(sources, &report)
+	l.lintDuplicateIDs(sources, &report)
+
+	for _, problems := range [][]lintProblem{report.Problems, report.Warnings} {
+		sort.SliceStable(problems, func(i, j int) bool {
+			return problems[i].Path < problems[j].Path
+		})
+	}
+
+	if err := l.writeReport(report); err != nil {
+		return err
+	}
+
+	if len(report.Problems) > 0 {
+		return lintFailure{problems: len(report.Problems)}
+	}
+
+	return nil
+}
+
+// validate sanity checks the input variables
+func (l *Lint) validate() error {
+	if l.InPath == "" {
+		return errors.New("input path is empty")
+	}
+
+	if l.Importer == nil {
+		return errors.New("must provide an importer")
+	}
+
+	if l.Out == nil {
+		return errors.New("must provide a report destination")
+	}
+
+	if l.Workers < 1 {
+		return errors.New("must provide at least one worker")
+	}
+
+	if l.DateBy == "" {
+		return errors.New("must provide a timestamp to date notes by")
+	}
+
+	return nil
+}
+
+// lintNotes imports a Note from each of the provided sources, adding a problem to the provided report for each
+// note that fails to import or has a timestamp warning, and a warning for each note that shares its date and title
+// with another note
+func (l *Lint) lintNotes(sources []string, report *lintReport) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	filenames := make(map[string][]string)
+	var order []string
+
+	for r := range domain.StreamNotes(ctx, sources, l.Workers, l.Importer.Import) {
+		if r.Err != nil {
+			report.Problems = append(report.Problems, lintProblem{Kind: l.parseProblemKind(r.Err), Path: r.Source, Detail: r.Err.Error()})
+			continue
+		}
+
+		for _, w := range r.Note.Warnings {
+			report.Problems = append(report.Problems, lintProblem{Kind: invalidTimestampProblem, Path: r.Source, Detail: w})
+		}
+
+		r.Note.DateBy = l.DateBy
+		filename := r.Note.Filename()
+
+		if _, ok := filenames[filename]; !ok {
+			order = append(order, filename)
+		}
+		filenames[filename] = append(filenames[filename], r.Source)
+	}
+
+	for _, filename := range order {
+		paths := filenames[filename]
+		if len(paths) < 2 {
+			continue
+		}
+
+		for _, p := range paths {
+			report.Warnings = append(report.Warnings, lintProblem{
+				Kind:   duplicateFilenameProblem,
+				Path:   p,
+				Detail: fmt.Sprintf("filename %s is shared by %d notes, so each will be suffixed", filename, len(paths)),
+			})
+		}
+	}
+}
+
+// lintDuplicateIDs adds a warning to the provided report for each of the provided raw note directories whose id
+// is shared with a directory in another folder, as the id of a gnotes note is the name of its directory
+//
+// Cleaning qualifies notes that share an id by their folder, so a shared id is only a problem if the notes
+// would also share the directory that their attachments are written to.
+func (l *Lint) lintDuplicateIDs(dirs []string, report *lintReport) {
+	ids := make(map[string][]string)
+	attachmentDirs := make(map[string][]string)
+
+	attachmentDir := func(dir string) string {
+		folder := l.Files.ParseBase(l.Files.ParseDir(dir))
+		return domain.AttachmentDir(folder, l.Files.ParseBase(dir))
+	}
+
+	for _, dir := range dirs {
+		id := l.Files.ParseBase(dir)
+		ids[id] = append(ids[id], dir)
+
+		key := strings.ToLower(attachmentDir(dir))
+		attachmentDirs[key] = append(attachmentDirs[key], dir)
+	}
+
+	for _, dir := range dirs {
+		id := l.Files.ParseBase(dir)
+		if len(ids[id]) < 2 {
+			continue
+		}
+
+		if shared := attachmentDirs[strings.ToLower(attachmentDir(dir))]; len(shared) > 1 {
+			report.Problems = append(report.Problems, lintProblem{
+				Kind:   duplicateIDProblem,
+				Path:   dir,
+				Detail: fmt.Sprintf("id %s is shared by %d directories whose attachments would all be written to %s", id, len(shared), attachmentDir(dir)),
+			})
+			continue
+		}
+
+		report.Warnings = append(report.Warnings, lintProblem{
+			Kind:   sharedIDProblem,
+			Path:   dir,
+			Detail: fmt.Sprintf("id %s is shared by %d directories in different folders, so each will be qualified by its folder", id, len(ids[id])),
+		})
+	}
+}
+
+// writeReport writes the provided report to the output, either as json or as a human summary
+func (l *Lint) writeReport(report lintReport) error {
+	if l.JSON {
+		b, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("cannot json encode report: %w", err)
+		}
+
+		if _, err := fmt.Fprintln(l.Out, string(b)); err != nil {
+			return fmt.Errorf("cannot write report: %w", err)
+		}
+
+		return nil
+	}
+
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "export: %s\n", report.Path)
+	fmt.Fprintf(&sb, "notes: %d\n", report.Notes)
+	if report.Locale != "" {
+		fmt.Fprintf(&sb, "header labels: %s\n", report.Locale)
+	}
+	if report.Layout != "" {
+		fmt.Fprintf(&sb, "timestamp layout: %s\n", report.Layout)
+	}
+
+	counts := make(map[lintProblemKind]int)
+	for _, p := range report.Problems {
+		counts[p.Kind]++
+		fmt.Fprintf(&sb, "[%s] %s: %s\n", p.Kind, p.Path, p.Detail)
+	}
+
+	if len(report.Problems) == 0 {
+		sb.WriteString("no problems found\n")
+	}
+
+	for _, p := range report.Warnings {
+		counts[p.Kind]++
+		fmt.Fprintf(&sb, "warning [%s] %s: %s\n", p.Kind, p.Path, p.Detail)
+	}
+
+	for _, kind := range []lintProblemKind{
+		missingContentProblem,
+		unknownFormatProblem,
+		unknownHeaderProblem,
+		invalidTimestampProblem,
+		parseErrorProblem,
+		duplicateIDProblem,
+		sharedIDProblem,
+		duplicateFilenameProblem,
+	} {
+		if counts[kind] > 0 {
+			fmt.Fprintf(&sb, "%s: %d\n", kind, counts[kind])
+		}
+	}
+
+	if _, err := io.WriteString(l.Out, sb.String()); err != nil {
+		return fmt.Errorf("cannot write report: %w", err)
+	}
+
+	return nil
+}
+
+// parseProblemKind returns the kind of problem that the provided import error represents
+func (l *Lint) parseProblemKind(err error) lintProblemKind {
+	switch {
+	case l.Files.IsNotExist(err):
+		return missingContentProblem
+	case errors.Is(err, domain.ErrUnknownHeader):
+		return unknownHeaderProblem
+	case errors.Is(err, domain.ErrInvalidTimestamp):
+		return invalidTimestampProblem
+	}
+
+	return parseErrorProblem
+}
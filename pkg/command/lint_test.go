@@ -0,0 +1,199 @@
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reorg/pkg/adapters"
+	"reorg/pkg/domain"
+	"testing"
+	"time"
+)
+
+// rawNote returns the raw html of a note with the provided title, written by an english gnotes export
+func rawNote(title string) string {
+	return fmt.Sprintf(
+		`<html><body><div><a href="#">Back</a> %s</div><br><br>Create Time: 25/03/2021 10:00<br>Modify Time: 26/03/2021 11:30<br><br><p>%s content</p></body></html>`,
+		title, title,
+	)
+}
+
+// writeExport writes an export of the provided raw note directories, by path relative to the export,
+// to a temporary directory, returning the export's path
+//
+// Directories whose content is empty are written without a content.html.
+func writeExport(t *testing.T, dirs map[string]string) string {
+	root := t.TempDir()
+
+	for dir, content := range dirs {
+		p := filepath.Join(root, filepath.FromSlash(dir))
+		if err := os.MkdirAll(p, 0755); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if content == "" {
+			continue
+		}
+
+		if err := ioutil.WriteFile(filepath.Join(p, "content.html"), []byte(content), 0644); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	return root
+}
+
+// newLint returns a Lint of the export at the provided path, which reports to the provided buffer as json
+func newLint(t *testing.T, inPath string, out *bytes.Buffer) *Lint {
+	tp, err := domain.NewTimestampParser(time.UTC, domain.DefaultTimestampLayouts)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	fs := &adapters.OsFileSystem{}
+	files := domain.NewFileSystemService(fs)
+
+	return &Lint{
+		InPath:   inPath,
+		Importer: &adapters.GNotesImporter{Files: files, Notes: domain.NewNoteService(fs, domain.WithTimestampParser(tp))},
+		JSON:     true,
+		Out:      out,
+		DateBy:   domain.CreatedTimestamp,
+		Workers:  2,
+		Files:    files,
+	}
+}
+
+func TestLint(t *testing.T) {
+	tt := []struct {
+		name         string
+		dirs         map[string]string
+		wantCode     int
+		wantProblems []lintProblemKind
+		wantWarnings []lintProblemKind
+	}{
+		{
+			name:     "valid export has no problems",
+			dirs:     map[string]string{"Other/101": rawNote("Shopping"), "Work/102": rawNote("Budget")},
+			wantCode: 0,
+		},
+		{
+			name:         "ids shared across folders are warnings",
+			dirs:         map[string]string{"Other/101": rawNote("Shopping"), "Trash/101": rawNote("Budget")},
+			wantCode:     0,
+			wantWarnings: []lintProblemKind{sharedIDProblem, sharedIDProblem},
+		},
+		{
+			name:         "ids shared by folders whose attachment directories clash are problems",
+			dirs:         map[string]string{"A:B/101": rawNote("Shopping"), "A_B/101": rawNote("Budget")},
+			wantCode:     1,
+			wantProblems: []lintProblemKind{duplicateIDProblem, duplicateIDProblem},
+		},
+		{
+			name:         "shared filenames are warnings",
+			dirs:         map[string]string{"Other/101": rawNote("Shopping"), "Other/102": rawNote("Shopping")},
+			wantCode:     0,
+			wantWarnings: []lintProblemKind{duplicateFilenameProblem, duplicateFilenameProblem},
+		},
+		{
+			name:         "missing content is a problem",
+			dirs:         map[string]string{"Other/101": rawNote("Shopping"), "Other/102": ""},
+			wantCode:     1,
+			wantProblems: []lintProblemKind{missingContentProblem},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			root := writeExport(t, tc.dirs)
+
+			err := newLint(t, root, &out).Run()
+
+			var code int
+			if err != nil {
+				var ec exitCoder
+				if !errors.As(err, &ec) {
+					t.Fatalf("got error %q, want an expected failure", err)
+				}
+				code = ec.ExitCode()
+			}
+
+			if code != tc.wantCode {
+				t.Errorf("got exit code %d, want %d", code, tc.wantCode)
+			}
+
+			var report lintReport
+			if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if report.Path != root || report.Notes != len(tc.dirs) {
+				t.Errorf("got path %q with %d notes, want %q with %d notes", report.Path, report.Notes, root, len(tc.dirs))
+			}
+
+			if report.Locale != "en" {
+				t.Errorf("got locale %q, want %q", report.Locale, "en")
+			}
+
+			if got := problemKinds(report.Problems); fmt.Sprint(got) != fmt.Sprint(tc.wantProblems) {
+				t.Errorf("got problems %v, want %v", got, tc.wantProblems)
+			}
+
+			if got := problemKinds(report.Warnings); fmt.Sprint(got) != fmt.Sprint(tc.wantWarnings) {
+				t.Errorf("got warnings %v, want %v", got, tc.wantWarnings)
+			}
+		})
+	}
+}
+
+func TestLint_SetupFailure(t *testing.T) {
+	tt := []struct {
+		name   string
+		inPath string
+		mutate func(l *Lint)
+	}{
+		{
+			name:   "missing export cannot be linted",
+			inPath: filepath.Join(t.TempDir(), "missing"),
+		},
+		{
+			name:   "invalid workers cannot be linted",
+			inPath: t.TempDir(),
+			mutate: func(l *Lint) { l.Workers = 0 },
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+
+			l := newLint(t, tc.inPath, &out)
+			if tc.mutate != nil {
+				tc.mutate(l)
+			}
+
+			var ec exitCoder
+			if err := l.Run(); !errors.As(err, &ec) || ec.ExitCode() != 2 {
+				t.Errorf("got error %v, want an expected failure with exit code 2", err)
+			}
+
+			if out.Len() != 0 {
+				t.Errorf("got report %q, want none", out.String())
+			}
+		})
+	}
+}
+
+// problemKinds returns the kind of each of the provided problems, in order
+func problemKinds(problems []lintProblem) []lintProblemKind {
+	var kinds []lintProblemKind
+	for _, p := range problems {
+		kinds = append(kinds, p.Kind)
+	}
+	return kinds
+}
@@ -0,0 +1,151 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"reorg/pkg/domain"
+)
+
+// Reclean represents our reclean command
+type Reclean struct {
+	runner
+	InPath string
+	Rules  *domain.RuleSet // user-defined rules applied by the note service, whose matches are reported
+	Files  *domain.FileSystemService
+	Notes  *domain.NoteService
+}
+
+// Run implements Runner
+func (r *Reclean) Run() error {
+	if err := r.validate(); err != nil {
+		return fmt.Errorf("validation error: %w", err)
+	}
+
+	var err error
+
+	r.InPath, err = r.Files.ParseAbsPath(r.InPath)
+	if err != nil {
+		return fmt.Errorf("cannot parse absolute path %s: %w", r.InPath, err)
+	}
+
+	if err := r.Files.DirExists(r.InPath); err != nil {
+		return fmt.Errorf("cannot find directory %s: %w", r.InPath, err)
+	}
+
+	if n := r.Rules.Len(); n > 0 {
+		log.Printf("applying %d user-defined rules", n)
+	}
+
+	log.Printf("scanning directory: %s", r.InPath)
+
+	files, err := r.Files.GetChildPaths(
+		r.InPath,
+		&domain.IsNotDir{},
+		&domain.IsJSON{},
+		&domain.IsNotName{BaseNames: []string{manifestFileName, reportFileName}},
+	)
+	if err != nil {
+		return fmt.Errorf("validation error: %w", err)
+	}
+
+	if len(files) == 0 {
+		return fmt.Errorf("no json files found in parent: %s", r.InPath)
+	}
+
+	log.Printf("%d notes to re-clean in place", len(files))
+
+	if !cont() {
+		return errors.New("aborted")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var recleaned, changed, skipped int
+
+	for res := range domain.StreamNotes(ctx, files, domain.DefaultWorkers, r.recleanFile) {
+		if res.Err != nil {
+			return fmt.Errorf("cannot re-clean note from file %s: %w", res.Source, res.Err)
+		}
+
+		if res.Note.RawHTML == "" {
+			skipped++
+			continue
+		}
+
+		written, err := r.writeFile(res.Source, res.Note)
+		if err != nil {
+			return err
+		}
+
+		recleaned++
+		if written {
+			changed++
+		}
+	}
+
+	log.Printf("re-cleaned %d notes, of which %d changed", recleaned, changed)
+
+	for _, rc := range r.Rules.Counts() {
+		log.Printf("rule %q matched %d times", rc.Name, rc.Matches)
+	}
+
+	if skipped > 0 {
+		log.Printf("WARNING: skipped %d notes that do not retain their raw html (clean with -raw to retain it)", skipped)
+	}
+
+	return nil
+}
+
+// validate sanity checks the input variables
+func (r *Reclean) validate() error {
+	if r.InPath == "" {
+		return errors.New("input path is empty")
+	}
+
+	return nil
+}
+
+// recleanFile re-cleans the Note within the file at the provided path,
+// returning the Note unchanged if it does not retain its raw html
+func (r *Reclean) recleanFile(path string) (domain.Note, error) {
+	n, err := r.Notes.ParseFromFile(path)
+	if err != nil {
+		return domain.Note{}, err
+	}
+
+	recleaned, err := r.Notes.RecleanNote(n)
+	if errors.Is(err, domain.ErrNoRawHTML) {
+		return n, nil
+	}
+
+	return recleaned, err
+}
+
+// writeFile writes the provided Note to the file at the provided path in place,
+// returning false if the file already has the same contents
+func (r *Reclean) writeFile(path string, n domain.Note) (bool, error) {
+	buf := bytes.NewBuffer(nil)
+	if err := json.NewEncoder(buf).Encode(&n); err != nil {
+		return false, fmt.Errorf("cannot parse json: %w", err)
+	}
+
+	existing, err := r.Files.ReadFile(path)
+	if err != nil {
+		return false, fmt.Errorf("cannot read file %s: %w", path, err)
+	}
+
+	if bytes.Equal(existing, buf.Bytes()) {
+		return false, nil
+	}
+
+	if err := r.Files.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return false, fmt.Errorf("cannot write note with id %s: %w", n.ID, err)
+	}
+
+	return true, nil
+}
@@ -12,10 +12,12 @@ import (
 // Store represents our store command
 type Store struct {
 	runner
-	InPath string
-	Writer domain.NoteWriter
-	Files  *domain.FileSystemService
-	Notes  *domain.NoteService
+	InPath   string
+	Paths    []*domain.PathTemplate // candidate templates of note paths, in order of preference (category and filename by default)
+	Archived domain.ArchiveMode     // how archived notes are stored
+	Writer   domain.NoteWriter
+	Files    *domain.FileSystemService
+	Notes    *domain.NoteService
 }
 
 // Run implements Runner
@@ -41,7 +43,7 @@ func (s *Store) Run() error {
 		s.InPath,
 		&domain.IsNotDir{},
 		&domain.IsJSON{},
-		&domain.IsNotName{BaseNames: []string{manifestFileName}},
+		&domain.IsNotName{BaseNames: []string{manifestFileName, reportFileName}},
 	)
 	if err != nil {
 		return fmt.Errorf("validation error: %w", err)
@@ -58,6 +60,12 @@ func (s *Store) Run() error {
 		return fmt.Errorf("cannot parse notes: %w", err)
 	}
 
+	// notes cleaned before filenames were disambiguated may share a filename
+	notes, err = s.Notes.AssignFilenames(notes)
+	if err != nil {
+		return fmt.Errorf("cannot assign filenames: %w", err)
+	}
+
 	log.Println("parsing manifest from file...")
 
 	manifestPath, err := s.Files.ParseAbsPath(s.InPath, manifestFileName)
@@ -86,6 +94,27 @@ func (s *Store) Run() error {
 	notes = s.Notes.FilterNotesByManifest(notes, manifest, true)
 	notes = s.Notes.EnrichNoteCategories(notes, manifest)
 
+	// the default path is rendered like any other, so that a category from the manifest cannot lead outside of storage
+	paths := s.Paths
+	if len(paths) == 0 {
+		pt, err := domain.ParsePathTemplate(domain.DefaultPathTemplate)
+		if err != nil {
+			return err
+		}
+		paths = []*domain.PathTemplate{pt}
+	}
+
+	notes, pt, err := s.Notes.ApplyPathTemplates(notes, paths, false)
+	if err != nil {
+		return fmt.Errorf("cannot apply path templates: %w", err)
+	}
+
+	log.Printf("writing notes using path template: %s", pt)
+
+	if notes, err = s.Notes.ApplyArchiveMode(notes, s.Archived); err != nil {
+		return fmt.Errorf("cannot apply archive mode: %w", err)
+	}
+
 	log.Printf("%d notes moving to storage", len(notes))
 
 	if !cont() {
@@ -101,6 +130,35 @@ func (s *Store) Run() error {
 		return fmt.Errorf("moving notes failed: %w", err)
 	}
 
+	if err := s.writeIndexes(notes); err != nil {
+		return fmt.Errorf("cannot write indexes: %w", err)
+	}
+
+	return nil
+}
+
+// writeIndexes writes an index of the provided Notes for each of their tags and for each of the starred and pinned flags,
+// if supported by the writer
+func (s *Store) writeIndexes(notes []domain.Note) error {
+	indexes := append(s.Notes.BuildFlagIndexes(notes), s.Notes.BuildTagIndexes(notes)...)
+	if len(indexes) == 0 {
+		return nil
+	}
+
+	iw, ok := s.Writer.(domain.IndexWriter)
+	if !ok {
+		log.Printf("WARNING: writer cannot write indexes, skipping %d indexes", len(indexes))
+		return nil
+	}
+
+	for _, idx := range indexes {
+		if err := iw.WriteIndex(s.InPath, idx); err != nil {
+			return err
+		}
+	}
+
+	log.Printf("written %d indexes", len(indexes))
+
 	return nil
 }
 
@@ -114,5 +172,9 @@ func (s *Store) validate() error {
 		return errors.New("must provide a note writer")
 	}
 
+	if s.Archived == "" {
+		return errors.New("must provide an archive mode")
+	}
+
 	return nil
 }
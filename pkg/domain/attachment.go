@@ -0,0 +1,181 @@
+package domain
+
+import (
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// AttachmentsDir defines the directory, relative to a Note, that its attachments are stored within
+const AttachmentsDir = "attachments"
+
+// inlineAttachmentPrefix defines the prefix of the name of an attachment that was extracted from a data uri
+const inlineAttachmentPrefix = "inline_"
+
+// dataURIRgx matches a html attribute whose value is a data uri
+var dataURIRgx = regexp.MustCompile(`(?i)(src|href)=(["'])data:([^;,"']*)((?:;[^;,"']*)*),([^"']*)["']`)
+
+// preferredExtensions defines the file extension to use for common mime types,
+// where the standard library would otherwise pick the first of several alphabetically
+var preferredExtensions = map[string]string{
+	"image/jpeg":    ".jpg",
+	"image/png":     ".png",
+	"image/gif":     ".gif",
+	"image/webp":    ".webp",
+	"image/svg+xml": ".svg",
+	"audio/mpeg":    ".mp3",
+	"audio/mp4":     ".m4a",
+	"audio/amr":     ".amr",
+	"audio/ogg":     ".ogg",
+	"video/mp4":     ".mp4",
+	"text/plain":    ".txt",
+}
+
+// AttachmentDir returns the directory, relative to a Note, that the attachments of the note with the provided
+// source folder and id are stored within
+//
+// Notes in different folders may share an id, so the directory is qualified by the folder.
+func AttachmentDir(folder, id string) string {
+	if folder = escapePathField(folder); folder == "" {
+		return path.Join(AttachmentsDir, id)
+	}
+
+	return path.Join(AttachmentsDir, folder, id)
+}
+
+// ParseAttachmentsFromDir parses Attachments of the Note with the provided source folder and id from every file
+// nested within the provided raw note directory, excluding those whose base names match any of the provided exclusions
+func (ns *NoteService) ParseAttachmentsFromDir(dir, folder, id string, exclude ...string) ([]Attachment, error) {
+	return ns.parseAttachmentsFromDir(dir, AttachmentDir(folder, id), exclude)
+}
+
+// parseAttachmentsFromDir recursively parses Attachments from the provided directory, relative to the provided path
+func (ns *NoteService) parseAttachmentsFromDir(dir, rel string, exclude []string) ([]Attachment, error) {
+	infos, err := ns.fs.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var attachments []Attachment
+
+	for _, info := range infos {
+		if !fileIsValid(info, []FileValidator{&IsNotName{BaseNames: exclude}}) {
+			continue
+		}
+
+		full := fmt.Sprintf("%s/%s", dir, info.Name())
+
+		if info.IsDir() {
+			nested, err := ns.parseAttachmentsFromDir(full, path.Join(rel, info.Name()), nil)
+			if err != nil {
+				return nil, err
+			}
+			attachments = append(attachments, nested...)
+			continue
+		}
+
+		mimeType, err := ns.detectMimeType(full)
+		if err != nil {
+			return nil, fmt.Errorf("cannot detect mime type of %s: %w", full, err)
+		}
+
+		attachments = append(attachments, Attachment{
+			Path:       path.Join(rel, info.Name()),
+			MimeType:   mimeType,
+			Size:       info.Size(),
+			SourcePath: full,
+		})
+	}
+
+	return attachments, nil
+}
+
+// detectMimeType detects the mime type of the file at the provided path, by its extension or otherwise its content
+func (ns *NoteService) detectMimeType(p string) (string, error) {
+	if t := mime.TypeByExtension(path.Ext(p)); t != "" {
+		return t, nil
+	}
+
+	b, err := ns.fs.ReadFile(p)
+	if err != nil {
+		return "", err
+	}
+
+	return http.DetectContentType(b), nil
+}
+
+// extractInlineAttachments extracts Attachments from any data uris within the provided raw input to the provided
+// attachment directory, returning the input with each data uri replaced by a reference to its extracted Attachment
+func extractInlineAttachments(inp, dir string) (string, []Attachment, error) {
+	var attachments []Attachment
+	var extractErr error
+
+	replaced := dataURIRgx.ReplaceAllStringFunc(inp, func(match string) string {
+		parts := dataURIRgx.FindStringSubmatch(match)
+		attr, quote, mimeType, params, payload := parts[1], parts[2], parts[3], parts[4], parts[5]
+
+		data, err := decodeDataURI(params, payload)
+		if err != nil {
+			if extractErr == nil {
+				extractErr = fmt.Errorf("cannot decode data uri: %w", err)
+			}
+			return match
+		}
+
+		if mimeType == "" {
+			mimeType = http.DetectContentType(data)
+		}
+
+		name := fmt.Sprintf("%s%d%s", inlineAttachmentPrefix, len(attachments)+1, extensionByType(mimeType))
+		a := Attachment{
+			Path:     path.Join(dir, name),
+			MimeType: mimeType,
+			Size:     int64(len(data)),
+			Data:     data,
+		}
+		attachments = append(attachments, a)
+
+		return fmt.Sprintf("%s=%s%s%s", attr, quote, a.Path, quote)
+	})
+
+	if extractErr != nil {
+		return "", nil, extractErr
+	}
+
+	return replaced, attachments, nil
+}
+
+// decodeDataURI decodes the payload of a data uri with the provided parameters
+func decodeDataURI(params, payload string) ([]byte, error) {
+	if strings.Contains(strings.ToLower(params), ";base64") {
+		payload = strings.Join(strings.Fields(payload), "")
+		return base64.StdEncoding.DecodeString(payload)
+	}
+
+	unescaped, err := url.PathUnescape(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(unescaped), nil
+}
+
+// extensionByType returns the file extension to use for the provided mime type
+func extensionByType(mimeType string) string {
+	mimeType = strings.ToLower(strings.TrimSpace(strings.Split(mimeType, ";")[0]))
+
+	if ext, ok := preferredExtensions[mimeType]; ok {
+		return ext
+	}
+
+	if exts, err := mime.ExtensionsByType(mimeType); err == nil && len(exts) > 0 {
+		return exts[0]
+	}
+
+	return ".bin"
+}
@@ -0,0 +1,19 @@
+package domain
+
+import "testing"
+
+func TestAttachmentDir(t *testing.T) {
+	other, trash := AttachmentDir("Other", "101"), AttachmentDir("Trash", "101")
+
+	if other == trash {
+		t.Fatalf("got %q for notes with the same id in different folders, want different directories", other)
+	}
+
+	if got, want := AttachmentDir("Work: Q1/Q2", "101"), "attachments/Work_ Q1_Q2/101"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	if got, want := AttachmentDir("", "101"), "attachments/101"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,103 @@
+package domain
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// checkboxRgx matches a html checkbox input
+var checkboxRgx = regexp.MustCompile(`(?i)<input[^>]*type=["']?checkbox["']?[^>]*>`)
+
+// checkedRgx matches the checked attribute of a html input
+var checkedRgx = regexp.MustCompile(`(?i)\schecked(\s|=|/|>)`)
+
+// checklistItemRgx matches a line of content that begins with a ballot box, which represents a checklist item,
+// tolerating leading indentation and a list marker
+//
+// Checkbox inputs are converted to ballot boxes before content is parsed. Other markers, such as ticks or bracketed
+// crosses, are not matched as they are just as likely to begin a line of prose.
+var checklistItemRgx = regexp.MustCompile(`^(\s*(?:[-*]\s+)?)(☐|☑|☒)\s*(.*\S)\s*$`)
+
+// checklistDoneMarkers defines the checklist item markers that represent a checked off item
+var checklistDoneMarkers = []string{"☑", "☒"}
+
+// markCheckboxes replaces each html checkbox input within the provided raw input with a ballot box
+func markCheckboxes(inp string) string {
+	return checkboxRgx.ReplaceAllStringFunc(inp, func(input string) string {
+		if checkedRgx.MatchString(input) {
+			return "☑ "
+		}
+		return "☐ "
+	})
+}
+
+// parseChecklist parses checklist items from the provided note content,
+// returning the items along with the content in which each item is rendered in place as a `[x]` / `[ ]` line,
+// or as a task list item if the content is markdown
+func parseChecklist(content string, mode ContentMode) ([]ChecklistItem, string) {
+	var items []ChecklistItem
+	var lines []string
+
+	for _, line := range strings.Split(content, "\n") {
+		matches := checklistItemRgx.FindStringSubmatch(line)
+		if matches == nil {
+			lines = append(lines, line)
+			continue
+		}
+
+		item := ChecklistItem{
+			Text: matches[3],
+			Done: isDoneMarker(matches[2]),
+		}
+
+		prefix := matches[1]
+		if mode == MarkdownContent && strings.TrimSpace(prefix) == "" {
+			prefix += "- "
+		}
+
+		items = append(items, item)
+		lines = append(lines, prefix+item.String())
+	}
+
+	if len(items) == 0 {
+		return nil, content
+	}
+
+	return items, strings.Join(lines, "\n")
+}
+
+// isDoneMarker returns true if the provided checklist item marker represents a checked off item
+func isDoneMarker(marker string) bool {
+	for _, m := range checklistDoneMarkers {
+		if strings.Contains(marker, m) {
+			return true
+		}
+	}
+	return false
+}
+
+// String implements fmt.Stringer, rendering the item as a `[x]` / `[ ]` line
+func (c ChecklistItem) String() string {
+	marker := "[ ]"
+	if c.Done {
+		marker = "[x]"
+	}
+
+	return fmt.Sprintf("%s %s", marker, c.Text)
+}
+
+// DetachedChecklist returns the checklist items of the Note that are not rendered within its content,
+// such as items that were exported separately from a note's body, or items of notes cleaned before
+// checklist items were kept in place
+func (n Note) DetachedChecklist() []ChecklistItem {
+	var detached []ChecklistItem
+
+	for _, item := range n.Checklist {
+		if !strings.Contains(n.Content, item.String()) {
+			detached = append(detached, item)
+		}
+	}
+
+	return detached
+}
@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseChecklist(t *testing.T) {
+	raw := `Shopping<br><input type="checkbox" checked> eggs<br><input type=checkbox> milk<br>✓ not an item<br>[x] nor this`
+
+	content, err := sanitiseInput(markCheckboxes(raw))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	items, got := parseChecklist(content, PlainTextContent)
+
+	wantItems := []ChecklistItem{{Text: "eggs", Done: true}, {Text: "milk"}}
+	if !reflect.DeepEqual(items, wantItems) {
+		t.Errorf("got items %+v, want %+v", items, wantItems)
+	}
+
+	if want := "Shopping\n[x] eggs\n[ ] milk\n✓ not an item\n[x] nor this\n"; got != want {
+		t.Errorf("got content %q, want %q", got, want)
+	}
+
+	_, got = parseChecklist("  ☐ milk\n", MarkdownContent)
+	if want := "  - [ ] milk\n"; got != want {
+		t.Errorf("got markdown content %q, want %q", got, want)
+	}
+}
+
+func TestNote_DetachedChecklist(t *testing.T) {
+	n := Note{
+		Content:   "Shopping\n[x] eggs\n",
+		Checklist: []ChecklistItem{{Text: "eggs", Done: true}, {Text: "bread"}},
+	}
+
+	want := []ChecklistItem{{Text: "bread"}}
+	if got := n.DetachedChecklist(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,175 @@
+package domain
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+// utf8Name defines the canonical name of the utf-8 encoding
+const utf8Name = "utf-8"
+
+// metaPrescanLen defines how many leading bytes of a raw file are searched for a meta charset declaration
+const metaPrescanLen = 4096
+
+// metaCharsetRgx matches the charset declared by a html meta element
+var metaCharsetRgx = regexp.MustCompile(`(?i)<meta[^>]+charset\s*=\s*["']?\s*([a-z0-9_:.-]+)`)
+
+// candidateEncodings defines the legacy encodings that are considered by heuristic detection
+var candidateEncodings = []string{"gbk", "shift_jis", "windows-1252"}
+
+// ParseEncoding returns the canonical name of the encoding represented by the provided label
+func ParseEncoding(label string) (string, error) {
+	enc, err := htmlindex.Get(label)
+	if err != nil {
+		return "", fmt.Errorf("invalid encoding: %s", label)
+	}
+
+	return htmlindex.Name(enc)
+}
+
+// decodeRaw transcodes the provided raw file contents to utf-8, returning the name of the source encoding
+//
+// The provided encoding is used if not empty, otherwise the encoding is detected.
+func decodeRaw(b []byte, forced string) (string, string, error) {
+	name := forced
+	if name == "" {
+		name = detectEncoding(b)
+	}
+
+	if name == utf8Name {
+		return strings.TrimPrefix(string(b), "\uFEFF"), name, nil
+	}
+
+	enc, err := htmlindex.Get(name)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid encoding: %s", name)
+	}
+
+	decoded, err := decode(enc, b)
+	if err != nil {
+		return "", "", fmt.Errorf("cannot decode as %s: %w", name, err)
+	}
+
+	// utf-16 decoders retain the byte order mark
+	return strings.TrimPrefix(decoded, "\uFEFF"), name, nil
+}
+
+// detectEncoding detects the encoding of the provided raw file contents, by byte order mark,
+// then by a non-utf-8 meta charset declaration, then by validity as utf-8, and otherwise by heuristics
+func detectEncoding(b []byte) string {
+	switch {
+	case bytes.HasPrefix(b, []byte{0xEF, 0xBB, 0xBF}):
+		return utf8Name
+	case bytes.HasPrefix(b, []byte{0xFE, 0xFF}):
+		return "utf-16be"
+	case bytes.HasPrefix(b, []byte{0xFF, 0xFE}):
+		return "utf-16le"
+	}
+
+	prescan := b
+	if len(prescan) > metaPrescanLen {
+		prescan = prescan[:metaPrescanLen]
+	}
+
+	// a declared utf-8 charset is not trusted if the content is not valid utf-8
+	if m := metaCharsetRgx.FindSubmatch(prescan); m != nil {
+		if name, err := ParseEncoding(string(m[1])); err == nil && name != utf8Name {
+			return name
+		}
+	}
+
+	if utf8.Valid(b) {
+		return utf8Name
+	}
+
+	best, bestScore := candidateEncodings[len(candidateEncodings)-1], 0
+	for idx, name := range candidateEncodings {
+		enc, err := htmlindex.Get(name)
+		if err != nil {
+			continue
+		}
+
+		decoded, err := decode(enc, b)
+		if err != nil {
+			continue
+		}
+
+		if score := scoreDecoded(name, decoded); idx == 0 || score > bestScore {
+			best, bestScore = name, score
+		}
+	}
+
+	return best
+}
+
+// decode decodes the provided bytes using the provided encoding
+func decode(enc encoding.Encoding, b []byte) (string, error) {
+	decoded, err := enc.NewDecoder().Bytes(b)
+	if err != nil {
+		return "", err
+	}
+
+	return string(decoded), nil
+}
+
+// scoreDecoded scores how plausibly the provided text was decoded using the encoding of the provided name
+//
+// Characters that are typical of the encoding's script score positively, while replacement characters,
+// control characters and characters that typically only result from mis-decoding score negatively.
+func scoreDecoded(name, text string) int {
+	runes := []rune(text)
+	score := 0
+
+	for i, r := range runes {
+		var prev, next rune
+		if i > 0 {
+			prev = runes[i-1]
+		}
+		if i < len(runes)-1 {
+			next = runes[i+1]
+		}
+
+		switch {
+		case r == utf8.RuneError,
+			unicode.IsControl(r) && r != '\n' && r != '\r' && r != '\t',
+			unicode.In(r, unicode.Co),
+			r >= 0xFF61 && r <= 0xFF9F: // half-width katakana
+			score -= 10
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			if name == "shift_jis" {
+				score += 3
+			}
+		case unicode.In(r, unicode.Han):
+			// genuine ideographic text occurs in runs, whereas mis-decoded text scatters ideographs amongst latin letters
+			if isIdeographic(prev) || isIdeographic(next) {
+				score += 2
+			} else if isASCIILetter(prev) || isASCIILetter(next) {
+				score -= 2
+			}
+		case r >= 0xC0 && r <= 0xFF:
+			// accented latin letters typically occur within words
+			if isASCIILetter(prev) || isASCIILetter(next) {
+				score++
+			}
+		}
+	}
+
+	return score
+}
+
+// isIdeographic returns true if the provided rune is a CJK ideograph, kana or CJK punctuation
+func isIdeographic(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana) || (r >= 0x3000 && r <= 0x303F) || (r >= 0xFF01 && r <= 0xFF60)
+}
+
+// isASCIILetter returns true if the provided rune is an ascii letter
+func isASCIILetter(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
@@ -0,0 +1,117 @@
+package domain
+
+import (
+	"testing"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// mustEncode returns the provided text encoded using the provided encoding
+func mustEncode(t *testing.T, enc encoding.Encoding, text string) []byte {
+	t.Helper()
+
+	b, err := enc.NewEncoder().Bytes([]byte(text))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	return b
+}
+
+func TestDetectEncoding(t *testing.T) {
+	utf16le := unicode.UTF16(unicode.LittleEndian, unicode.UseBOM)
+
+	tt := []struct {
+		name string
+		inp  []byte
+		want string
+		text string // decoded text, if it is to be checked
+	}{
+		{
+			name: "utf-8 byte order mark is detected and stripped",
+			inp:  append([]byte{0xEF, 0xBB, 0xBF}, "Café"...),
+			want: "utf-8",
+			text: "Café",
+		},
+		{
+			name: "utf-16 byte order mark is detected",
+			inp:  mustEncode(t, utf16le, "会议记录"),
+			want: "utf-16le",
+			text: "会议记录",
+		},
+		{
+			name: "meta charset is used for content that is not utf-8",
+			inp:  append([]byte(`<meta charset="shift_jis">`), mustEncode(t, japanese.ShiftJIS, "会議")...),
+			want: "shift_jis",
+			text: `<meta charset="shift_jis">会議`,
+		},
+		{
+			name: "declared utf-8 charset is ignored when the content is not utf-8",
+			inp:  append([]byte(`<meta charset="utf-8">`), mustEncode(t, charmap.Windows1252, "Café crème brûlée")...),
+			want: "windows-1252",
+			text: `<meta charset="utf-8">Café crème brûlée`,
+		},
+		{
+			name: "valid utf-8 without a declaration is utf-8",
+			inp:  []byte("会议记录 Café"),
+			want: "utf-8",
+		},
+		{
+			name: "short gbk text is detected",
+			inp:  mustEncode(t, simplifiedchinese.GBK, "会议记录：预算"),
+			want: "gbk",
+			text: "会议记录：预算",
+		},
+		{
+			name: "short shift-jis text is detected",
+			inp:  mustEncode(t, japanese.ShiftJIS, "日本語のメモです"),
+			want: "shift_jis",
+			text: "日本語のメモです",
+		},
+		{
+			name: "short cp1252 text is detected",
+			inp:  mustEncode(t, charmap.Windows1252, "Café crème"),
+			want: "windows-1252",
+			text: "Café crème",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := detectEncoding(tc.inp); got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+
+			if tc.text == "" {
+				return
+			}
+
+			text, _, err := decodeRaw(tc.inp, "")
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if text != tc.text {
+				t.Errorf("got text %q, want %q", text, tc.text)
+			}
+		})
+	}
+}
+
+func TestDecodeRaw_Forced(t *testing.T) {
+	// a forced encoding is used without detection
+	inp := mustEncode(t, japanese.ShiftJIS, "日本語")
+
+	text, name, err := decodeRaw(inp, "shift_jis")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if name != "shift_jis" || text != "日本語" {
+		t.Errorf("got %q decoded as %q, want %q decoded as %q", text, name, "日本語", "shift_jis")
+	}
+}
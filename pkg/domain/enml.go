@@ -0,0 +1,20 @@
+package domain
+
+import "regexp"
+
+// enmlRules normalises evernote markup to the html that is expected by note parsing, applied in order
+var enmlRules = []findReplaceRule{
+	{find: regexp.MustCompile(`(?is)^.*?<en-note[^>]*>`), replace: ""},
+	{find: regexp.MustCompile(`(?is)</en-note>.*$`), replace: ""},
+	{find: regexp.MustCompile(`(?i)<en-todo[^>]*checked=["']true["'][^>]*>(\s*</en-todo>)?`), replace: `<input type="checkbox" checked>`},
+	{find: regexp.MustCompile(`(?i)<en-todo[^>]*>(\s*</en-todo>)?`), replace: `<input type="checkbox">`},
+	{find: regexp.MustCompile(`(?i)<en-media[^>]*>(\s*</en-media>)?`), replace: ""},
+	{find: regexp.MustCompile(`(?i)<br\s*/?>`), replace: "<br>"},
+	{find: regexp.MustCompile(`(?i)<div([^>]*)>`), replace: "<p$1>"},
+	{find: regexp.MustCompile(`(?i)</div>`), replace: "</p>"},
+}
+
+// NormaliseENML normalises the provided evernote markup to html
+func NormaliseENML(inp string) string {
+	return findReplace(inp, enmlRules)
+}
@@ -4,4 +4,5 @@ package domain
 type FileInfo interface {
 	IsDir() bool
 	Name() string
+	Size() int64
 }
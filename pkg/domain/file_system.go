@@ -1,15 +1,28 @@
 package domain
 
+import "io"
+
+// File represents an open file that can be read sequentially or randomly
+type File interface {
+	io.Reader
+	io.ReaderAt
+	io.Seeker
+	io.Closer
+}
+
 // FileSystem implements the behaviours of a file system
 type FileSystem interface {
 	ReadFile(path string) ([]byte, error)
+	Open(path string) (File, error)
 	WriteFile(path string, data []byte, perm uint32) error
 	ReadDir(path string) ([]FileInfo, error)
 	DirExists(path string) error
 	IsNotExist(err error) bool
 	Stat(path string) (FileInfo, error)
 	Mkdir(path string, perm uint32) error
+	MkdirAll(path string, perm uint32) error
 	RemoveAll(path string) error
+	Rename(oldPath, newPath string) error
 	Abs(pathParts ...string) (string, error)
 	Dir(path string) string
 	Base(path string) string
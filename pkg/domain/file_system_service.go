@@ -29,26 +29,86 @@ func (f *FileSystemService) GetChildPaths(parent string, validators ...FileValid
 	return paths, nil
 }
 
+// ReadFile reads the contents of the file at the provided path
+func (f *FileSystemService) ReadFile(path string) ([]byte, error) {
+	return f.fs.ReadFile(path)
+}
+
+// Open opens the file at the provided path for reading, which must be closed once read
+func (f *FileSystemService) Open(path string) (File, error) {
+	return f.fs.Open(path)
+}
+
 // WriteFile writes the provided data to the provided path using the provided file permissions
 func (f *FileSystemService) WriteFile(path string, data []byte, perm uint32) error {
 	return f.fs.WriteFile(path, data, perm)
 }
 
+// CopyDir recursively copies the directory at the provided source path to the provided destination path
+func (f *FileSystemService) CopyDir(src, dst string) error {
+	if err := f.fs.MkdirAll(dst, 0755); err != nil {
+		return fmt.Errorf("cannot make directory %s: %w", dst, err)
+	}
+
+	infos, err := f.fs.ReadDir(src)
+	if err != nil {
+		return err
+	}
+
+	for _, info := range infos {
+		srcPath := fmt.Sprintf("%s/%s", src, info.Name())
+		dstPath := fmt.Sprintf("%s/%s", dst, info.Name())
+
+		if info.IsDir() {
+			if err := f.CopyDir(srcPath, dstPath); err != nil {
+				return err
+			}
+			continue
+		}
+
+		data, err := f.fs.ReadFile(srcPath)
+		if err != nil {
+			return fmt.Errorf("cannot read file %s: %w", srcPath, err)
+		}
+
+		if err := f.fs.WriteFile(dstPath, data, 0644); err != nil {
+			return fmt.Errorf("cannot write file %s: %w", dstPath, err)
+		}
+	}
+
+	return nil
+}
+
 // DirExists returns an error if the provided path does not exist as a directory
 func (f *FileSystemService) DirExists(path string) error {
 	return f.fs.DirExists(path)
 }
 
+// IsNotExist returns true if the provided error reports that a path does not exist
+func (f *FileSystemService) IsNotExist(err error) bool {
+	return f.fs.IsNotExist(err)
+}
+
 // MakeDir attempts to make the directory at the given path
 func (f *FileSystemService) MakeDir(path string) error {
 	return f.fs.Mkdir(path, 0755)
 }
 
+// MakeDirAll attempts to make the directory at the given path along with any parents
+func (f *FileSystemService) MakeDirAll(path string) error {
+	return f.fs.MkdirAll(path, 0755)
+}
+
 // RemoveAll removess everything nested at the given path
 func (f *FileSystemService) RemoveAll(path string) error {
 	return f.fs.RemoveAll(path)
 }
 
+// Rename moves the file or directory at the given old path to the given new path
+func (f *FileSystemService) Rename(oldPath, newPath string) error {
+	return f.fs.Rename(oldPath, newPath)
+}
+
 // ParseAbsPath parses the absolute path of the provided components
 func (f *FileSystemService) ParseAbsPath(parts ...string) (string, error) {
 	abs, err := f.fs.Abs(parts...)
@@ -64,6 +124,11 @@ func (f *FileSystemService) ParseBase(path string) string {
 	return f.fs.Base(path)
 }
 
+// ParseDir returns all but the base component from the provided path
+func (f *FileSystemService) ParseDir(path string) string {
+	return f.fs.Dir(path)
+}
+
 // NewFileSystemService returns a new FileSystemService using the provided FileSystem
 func NewFileSystemService(fs FileSystem) *FileSystemService {
 	return &FileSystemService{fs: fs}
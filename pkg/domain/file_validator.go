@@ -31,6 +31,21 @@ func (i *IsJSON) Valid(f FileInfo) bool {
 	return strings.HasSuffix(f.Name(), ".json")
 }
 
+// IsName defines a file validator that checks for file name
+type IsName struct {
+	BaseNames []string
+}
+
+// Valid implements FileValidator.Valid()
+func (i *IsName) Valid(f FileInfo) bool {
+	for _, b := range i.BaseNames {
+		if b == f.Name() {
+			return true
+		}
+	}
+	return false
+}
+
 // IsNotName defines a file validator that checks for file name
 type IsNotName struct {
 	BaseNames []string
@@ -0,0 +1,169 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/mozillazg/go-pinyin"
+	"golang.org/x/text/unicode/norm"
+)
+
+// SlugMode defines how the letters of a title are retained within its slug
+type SlugMode string
+
+const (
+	// ASCIISlugs flattens common accents and drops any other letters that are not ascii, as slugs always have been
+	ASCIISlugs SlugMode = "ascii"
+	// UnicodeSlugs retains letters and digits of any script
+	UnicodeSlugs SlugMode = "unicode"
+	// TranslitSlugs transliterates letters to latin where possible, such as chinese to pinyin and cyrillic to latin,
+	// retaining letters of any other script
+	TranslitSlugs SlugMode = "translit"
+)
+
+// ParseSlugMode returns the SlugMode represented by the provided string
+func ParseSlugMode(s string) (SlugMode, error) {
+	switch m := SlugMode(s); m {
+	case ASCIISlugs, UnicodeSlugs, TranslitSlugs:
+		return m, nil
+	}
+
+	return "", fmt.Errorf("invalid slug mode: %s", s)
+}
+
+// windowsReservedNames defines the names that cannot be used as a file name on windows, regardless of extension
+var windowsReservedNames = map[string]bool{
+	"con": true, "prn": true, "aux": true, "nul": true,
+	"com1": true, "com2": true, "com3": true, "com4": true, "com5": true, "com6": true, "com7": true, "com8": true, "com9": true,
+	"lpt1": true, "lpt2": true, "lpt3": true, "lpt4": true, "lpt5": true, "lpt6": true, "lpt7": true, "lpt8": true, "lpt9": true,
+}
+
+// transliterations defines the latin spelling of letters that do not decompose into a latin letter and accents,
+// including letters whose conventional spelling differs from their decomposition, such as german umlauts
+var transliterations = map[rune]string{
+	// german
+	'ä': "ae", 'ö': "oe", 'ü': "ue", 'ß': "ss",
+	// other latin
+	'æ': "ae", 'ø': "o", 'œ': "oe", 'ł': "l", 'đ': "d", 'ð': "d", 'þ': "th", 'ı': "i",
+	// cyrillic
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "yo", 'ж': "zh", 'з': "z", 'и': "i",
+	'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t",
+	'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "", 'ы': "y", 'ь': "",
+	'э': "e", 'ю': "yu", 'я': "ya", 'і': "i", 'ї': "yi", 'є': "ye", 'ґ': "g", 'ў': "u", 'ј': "j", 'љ': "lj",
+	'њ': "nj", 'ћ': "c", 'ђ': "dj", 'џ': "dz",
+	// greek
+	'α': "a", 'β': "v", 'γ': "g", 'δ': "d", 'ε': "e", 'ζ': "z", 'η': "i", 'θ': "th", 'ι': "i", 'κ': "k",
+	'λ': "l", 'μ': "m", 'ν': "n", 'ξ': "x", 'ο': "o", 'π': "p", 'ρ': "r", 'σ': "s", 'ς': "s", 'τ': "t",
+	'υ': "y", 'φ': "f", 'χ': "ch", 'ψ': "ps", 'ω': "o",
+}
+
+// pinyinArgs defines the style that chinese characters are transliterated to, which is pinyin without tones
+var pinyinArgs = pinyin.NewArgs()
+
+// FilenamePolicy defines how a Note's title is converted to the slug that its filename is generated from
+type FilenamePolicy struct {
+	Mode     SlugMode // how letters are retained
+	Portable bool     // avoid names that cannot be written on windows, such as reserved device names
+}
+
+// Slugify returns a filename-safe representation of the provided title according to the FilenamePolicy
+//
+// Titles are normalised to NFC first, so that titles that differ only by their unicode composition share a slug.
+func (p FilenamePolicy) Slugify(title string) string {
+	title = norm.NFC.String(strings.ToLower(title))
+
+	var slug string
+
+	switch p.Mode {
+	case UnicodeSlugs:
+		slug = unicodeSlug(title)
+	case TranslitSlugs:
+		slug = unicodeSlug(transliterate(title))
+	default:
+		slug = Slugify(title)
+	}
+
+	if p.Portable {
+		slug = portableName(slug)
+	}
+
+	return slug
+}
+
+// transliterate returns the provided lowercase text with letters converted to latin where possible
+func transliterate(inp string) string {
+	var sb strings.Builder
+
+	for _, r := range inp {
+		if t, ok := transliterations[r]; ok {
+			sb.WriteString(t)
+			continue
+		}
+
+		if unicode.Is(unicode.Han, r) {
+			if py := pinyin.SinglePinyin(r, pinyinArgs); len(py) > 0 {
+				// separate each syllable so that it becomes a word of the slug
+				sb.WriteString(" " + py[0] + " ")
+				continue
+			}
+		}
+
+		// strip any accents, then transliterate the letter that remains
+		for _, d := range norm.NFD.String(string(r)) {
+			if unicode.Is(unicode.Mn, d) {
+				continue
+			}
+
+			if t, ok := transliterations[d]; ok {
+				sb.WriteString(t)
+				continue
+			}
+
+			sb.WriteRune(d)
+		}
+	}
+
+	return norm.NFC.String(sb.String())
+}
+
+// unicodeSlug returns the letters, digits and combining marks of the provided text, with each run of any other
+// characters replaced by a single hyphen
+func unicodeSlug(inp string) string {
+	var sb strings.Builder
+	var pending bool
+
+	for _, r := range inp {
+		switch {
+		case unicode.IsLetter(r), unicode.IsDigit(r), unicode.Is(unicode.M, r), r == '_':
+			if pending && sb.Len() > 0 {
+				sb.WriteRune('-')
+			}
+			pending = false
+			sb.WriteRune(r)
+		default:
+			pending = true
+		}
+	}
+
+	return sb.String()
+}
+
+// portableName returns the provided name adjusted so that it can be written on windows,
+// by removing trailing dots and spaces and suffixing reserved device names
+func portableName(name string) string {
+	name = strings.TrimRight(name, ". ")
+
+	if isWindowsReserved(name) {
+		name += "_"
+	}
+
+	return name
+}
+
+// isWindowsReserved returns true if the provided file name is a reserved device name on windows,
+// regardless of its case or extension
+func isWindowsReserved(name string) bool {
+	base := strings.ToLower(strings.SplitN(name, ".", 2)[0])
+	return windowsReservedNames[strings.TrimRight(base, " ")]
+}
@@ -0,0 +1,166 @@
+package domain
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// maxIDSuffixLen defines the maximum length of a note id that is used as a filename suffix,
+// beyond which a hash is used instead
+const maxIDSuffixLen = 20
+
+// hashSuffixLen defines the number of hex characters of a hash that is used as a filename suffix
+const hashSuffixLen = 8
+
+// FilenameSet assigns each Note a filename that is unique within the set, regardless of the order of assignment
+// of notes that do not collide
+type FilenameSet struct {
+	mux    sync.Mutex
+	taken  map[string]bool
+	shared map[string]int // number of reserved notes that share each unsuffixed filename
+	ids    map[string]int // number of reserved notes that share each unsuffixed filename and id
+}
+
+// Assign sets the filename suffix of the provided Note if its filename is already taken within the set,
+// then takes its filename
+//
+// Collisions are broken by the note's id, falling back to a short hash of its content and then its original path,
+// so that a note is given the same filename on every run over the same notes.
+func (fs *FilenameSet) Assign(n *Note) error {
+	fs.mux.Lock()
+	defer fs.mux.Unlock()
+
+	if fs.taken == nil {
+		fs.taken = make(map[string]bool)
+	}
+
+	if key := filenameKey(n.Filename()); !fs.taken[key] {
+		fs.taken[key] = true
+		return nil
+	}
+
+	return fs.assignSuffix(n, true)
+}
+
+// Reserve counts the unsuffixed filename of the provided Note, without taking it
+//
+// Every note must be reserved before any is assigned using AssignReserved.
+func (fs *FilenameSet) Reserve(n Note) {
+	fs.mux.Lock()
+	defer fs.mux.Unlock()
+
+	if fs.shared == nil {
+		fs.shared = make(map[string]int)
+		fs.ids = make(map[string]int)
+	}
+
+	n.FilenameSuffix = ""
+	key := filenameKey(n.Filename())
+
+	fs.shared[key]++
+	fs.ids[key+"\x00"+n.ID]++
+}
+
+// AssignReserved sets the filename suffix of the provided reserved Note if its unsuffixed filename is shared
+// by another reserved note, then takes its filename
+//
+// Since every note that shares a filename is suffixed, rather than all but the first, and a suffixed filename can
+// never displace the filename of a note that does not share it, the filename of a note does not depend upon the
+// order that notes are assigned in.
+func (fs *FilenameSet) AssignReserved(n *Note) error {
+	fs.mux.Lock()
+	defer fs.mux.Unlock()
+
+	if fs.taken == nil {
+		fs.taken = make(map[string]bool)
+	}
+
+	n.FilenameSuffix = ""
+	key := filenameKey(n.Filename())
+
+	if fs.shared[key] <= 1 && !fs.taken[key] {
+		fs.taken[key] = true
+		return nil
+	}
+
+	// notes that also share an id are all disambiguated by their content instead
+	return fs.assignSuffix(n, fs.ids[key+"\x00"+n.ID] <= 1)
+}
+
+// isTaken reports whether the provided filename key is taken within the set, or is the unsuffixed filename of
+// a single reserved note that it is held for
+func (fs *FilenameSet) isTaken(key string) bool {
+	return fs.taken[key] || fs.shared[key] == 1
+}
+
+// assignSuffix sets the filename suffix of the provided Note to the first candidate whose filename is not taken
+// within the set, then takes its filename
+//
+// If byID is false, the note's id is not a candidate.
+func (fs *FilenameSet) assignSuffix(n *Note, byID bool) error {
+	for _, suffix := range filenameSuffixes(*n, byID) {
+		candidate := *n
+		candidate.FilenameSuffix = suffix
+
+		if key := filenameKey(candidate.Filename()); !fs.isTaken(key) {
+			fs.taken[key] = true
+			n.FilenameSuffix = suffix
+			return nil
+		}
+	}
+
+	return fmt.Errorf("cannot assign unique filename to note with id %s: %s is taken", n.ID, n.Filename())
+}
+
+// filenameSuffixes returns the candidate suffixes that can disambiguate the filename of the provided Note, in order
+func filenameSuffixes(n Note, byID bool) []string {
+	var suffixes []string
+
+	if id := Slugify(n.ID); byID && id != "" && len(id) <= maxIDSuffixLen {
+		suffixes = append(suffixes, id)
+	}
+
+	return append(suffixes, shortHash(n.Content), shortHash(n.OriginalPath+"\n"+n.Content))
+}
+
+// filenameKey returns the key that the provided filename is taken by, so that filenames differing only by case
+// or unicode composition clash, as they do on case-insensitive and normalising filesystems
+func filenameKey(filename string) string {
+	return strings.ToLower(norm.NFC.String(filename))
+}
+
+// shortHash returns the leading hex characters of the sha1 hash of the provided input
+func shortHash(inp string) string {
+	sum := sha1.Sum([]byte(inp))
+	return hex.EncodeToString(sum[:])[:hashSuffixLen]
+}
+
+// AssignFilenames assigns each of the provided Notes a unique filename, suffixing every note whose unsuffixed
+// filename is shared by another note
+//
+// Since every note that shares a filename is suffixed, rather than all but the first, the filename of a note only
+// changes if it no longer shares its name or comes to share it, regardless of the order of the notes.
+func (ns *NoteService) AssignFilenames(notes []Note) ([]Note, error) {
+	var fs FilenameSet
+
+	for _, n := range notes {
+		fs.Reserve(n)
+	}
+
+	assigned := make([]Note, len(notes))
+
+	for idx, n := range notes {
+		// suffixes of notes that have already been cleaned are reassigned amongst the provided notes
+		if err := fs.AssignReserved(&n); err != nil {
+			return nil, err
+		}
+		assigned[idx] = n
+	}
+
+	return assigned, nil
+}
@@ -0,0 +1,90 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAssignFilenames(t *testing.T) {
+	created := time.Date(2021, 3, 5, 10, 0, 0, 0, time.UTC)
+
+	note := func(id, slug string) Note {
+		return Note{ID: id, Slug: slug, Content: "content of " + id, CreatedAt: created}
+	}
+
+	edited := func(id, slug string) Note {
+		n := note(id, slug)
+		n.Content = "edited " + n.Content
+		return n
+	}
+
+	tt := []struct {
+		name  string
+		notes []Note
+		want  []string
+	}{
+		{
+			name:  "unique filenames are not suffixed",
+			notes: []Note{note("101", "shopping"), note("102", "holiday")},
+			want:  []string{"2021-03-05_shopping", "2021-03-05_holiday"},
+		},
+		{
+			name:  "every note that shares a filename is suffixed",
+			notes: []Note{note("101", "shopping"), note("102", "shopping"), note("103", "holiday")},
+			want:  []string{"2021-03-05_shopping_101", "2021-03-05_shopping_102", "2021-03-05_holiday"},
+		},
+		{
+			name:  "suffixes do not depend upon order",
+			notes: []Note{note("102", "shopping"), note("101", "shopping")},
+			want:  []string{"2021-03-05_shopping_102", "2021-03-05_shopping_101"},
+		},
+		{
+			name:  "notes that share an id are all suffixed by a hash of their content",
+			notes: []Note{note("101", "shopping"), edited("101", "shopping"), note("102", "shopping")},
+			want: []string{
+				"2021-03-05_shopping_" + shortHash("content of 101"),
+				"2021-03-05_shopping_" + shortHash("edited content of 101"),
+				"2021-03-05_shopping_102",
+			},
+		},
+		{
+			name:  "suffixes of notes that share an id do not depend upon order",
+			notes: []Note{edited("101", "shopping"), note("101", "shopping")},
+			want: []string{
+				"2021-03-05_shopping_" + shortHash("edited content of 101"),
+				"2021-03-05_shopping_" + shortHash("content of 101"),
+			},
+		},
+		{
+			name:  "suffixes of notes that no longer share a filename are removed",
+			notes: []Note{func() Note { n := note("101", "shopping"); n.FilenameSuffix = "101"; return n }()},
+			want:  []string{"2021-03-05_shopping"},
+		},
+		{
+			name:  "suffixed filenames do not displace unique filenames",
+			notes: []Note{note("1", "a"), note("2", "a"), note("3", "a_1")},
+			want:  []string{"2021-03-05_a_" + shortHash("content of 1"), "2021-03-05_a_2", "2021-03-05_a_1"},
+		},
+	}
+
+	ns := NewNoteService(nil)
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ns.AssignFilenames(tc.notes)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if len(got) != len(tc.want) {
+				t.Fatalf("got %d notes, want %d", len(got), len(tc.want))
+			}
+
+			for idx, n := range got {
+				if fn := n.Filename(); fn != tc.want[idx] {
+					t.Errorf("note %d: got filename %q, want %q", idx, fn, tc.want[idx])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// HeaderProfile defines the labels that a raw note header is written with in a single locale
+type HeaderProfile struct {
+	Locale   string `json:"locale"`   // identifier of the profile, such as a language code
+	Back     string `json:"back"`     // label of the link that precedes the note title
+	Created  string `json:"created"`  // label that precedes the created timestamp, excluding its colon
+	Modified string `json:"modified"` // label that precedes the modified timestamp, excluding its colon
+}
+
+// DefaultHeaderProfiles defines the header profiles of the locales that gnotes exports are known to be written in
+//
+// Only the english labels, which were hard-coded before header profiles were introduced, have been verified against
+// real exports. Labels of other locales must be provided as custom profiles until they have been verified.
+var DefaultHeaderProfiles = []HeaderProfile{
+	{Locale: "en", Back: "Back", Created: "Create Time", Modified: "Modify Time"},
+}
+
+// headerLabels represents the patterns that match the labels of a single HeaderProfile
+type headerLabels struct {
+	profile  HeaderProfile
+	back     *regexp.Regexp
+	created  *regexp.Regexp
+	modified *regexp.Regexp
+}
+
+// newHeaderLabels returns the patterns that match the labels of the provided HeaderProfile
+//
+// Timestamp labels are matched case-insensitively and followed by either an ascii or full-width colon.
+func newHeaderLabels(p HeaderProfile) headerLabels {
+	timestampLabel := func(label string) *regexp.Regexp {
+		return regexp.MustCompile(`^\s*(?i:` + regexp.QuoteMeta(label) + `)\s*[:：]\s*`)
+	}
+
+	return headerLabels{
+		profile:  p,
+		back:     regexp.MustCompile(`^\s*` + regexp.QuoteMeta(p.Back) + `\s*`),
+		created:  timestampLabel(p.Created),
+		modified: timestampLabel(p.Modified),
+	}
+}
+
+// isTimestampLine returns true if the provided line begins with either timestamp label
+func (l headerLabels) isTimestampLine(line string) bool {
+	return l.created.MatchString(line) || l.modified.MatchString(line)
+}
+
+// ParseHeaderProfiles parses HeaderProfiles from the provided json-encoded list
+func ParseHeaderProfiles(b []byte) ([]HeaderProfile, error) {
+	var profiles []HeaderProfile
+	if err := json.Unmarshal(b, &profiles); err != nil {
+		return nil, fmt.Errorf("cannot json decode header profiles: %w", err)
+	}
+
+	for idx, p := range profiles {
+		if err := p.validate(); err != nil {
+			return nil, fmt.Errorf("invalid header profile %d: %w", idx+1, err)
+		}
+	}
+
+	return profiles, nil
+}
+
+// validate sanity checks the labels of the HeaderProfile
+func (p HeaderProfile) validate() error {
+	switch {
+	case strings.TrimSpace(p.Locale) == "":
+		return errors.New("locale is empty")
+	case strings.TrimSpace(p.Back) == "":
+		return errors.New("back label is empty")
+	case strings.TrimSpace(p.Created) == "":
+		return errors.New("created label is empty")
+	case strings.TrimSpace(p.Modified) == "":
+		return errors.New("modified label is empty")
+	}
+
+	return nil
+}
@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// rawNote returns the raw html of a note whose header is written with the labels of the provided HeaderProfile
+func rawNote(p HeaderProfile, title string) []byte {
+	return []byte(fmt.Sprintf(
+		`<html><body><div><a href="#">%s</a> %s</div><br><br>%s: 25/03/2021 10:00<br>%s: 26/03/2021 11:30<br><br><p>eggs</p></body></html>`,
+		p.Back, title, p.Created, p.Modified,
+	))
+}
+
+func TestDefaultHeaderProfiles(t *testing.T) {
+	tp, err := NewTimestampParser(time.UTC, DefaultTimestampLayouts)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// header as written by an english export, whose labels were parsed before header profiles were introduced
+	english := []byte(`<html><body><div><a href="#">Back</a> Shopping</div><br><br>Create Time: 25/03/2021 10:00<br>Modify Time: 26/03/2021 11:30<br><br><p>eggs</p></body></html>`)
+
+	fs := &memFileSystem{files: map[string][]byte{
+		"/export/Other/101/content.html": english,
+		"/export/Other/102/content.html": rawNote(HeaderProfile{Back: "Zurück", Created: "Erstellt", Modified: "Geändert"}, "Einkauf"),
+	}}
+	ns := NewNoteService(fs, WithTimestampParser(tp))
+
+	format, err := ns.DetectRawFormat([]string{"/export/Other/101/content.html"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if format.Locale != "en" {
+		t.Errorf("got locale %q, want %q", format.Locale, "en")
+	}
+
+	n, err := ns.ParseFromRawFile("/export/Other/101/content.html", "Other", "101")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if n.Title != "Shopping" {
+		t.Errorf("got title %q, want %q", n.Title, "Shopping")
+	}
+
+	wantCreated := time.Date(2021, 3, 25, 10, 0, 0, 0, time.UTC)
+	wantModified := time.Date(2021, 3, 26, 11, 30, 0, 0, time.UTC)
+	if !n.CreatedAt.Equal(wantCreated) || !n.ModifiedAt.Equal(wantModified) {
+		t.Errorf("got timestamps %s and %s, want %s and %s", n.CreatedAt, n.ModifiedAt, wantCreated, wantModified)
+	}
+
+	if n.Content != "eggs\n" {
+		t.Errorf("got content %q, want %q", n.Content, "eggs\n")
+	}
+
+	// labels of other locales are not built in, so must be provided as custom profiles
+	if _, err := ns.DetectRawFormat([]string{"/export/Other/102/content.html"}); err == nil {
+		t.Error("got no error for an export with unknown labels, want error")
+	}
+}
+
+func TestParseHeaderProfiles(t *testing.T) {
+	profiles, err := ParseHeaderProfiles([]byte(`[{"locale": "da", "back": "Tilbage", "created": "Oprettet", "modified": "Ændret"}]`))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	ns := NewNoteService(&memFileSystem{files: map[string][]byte{
+		"/export/Other/101/content.html": rawNote(profiles[0], "Indkøb"),
+	}}, WithHeaderProfiles(profiles...))
+
+	n, err := ns.ParseFromRawFile("/export/Other/101/content.html", "Other", "101")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if n.Title != "Indkøb" {
+		t.Errorf("got title %q, want %q", n.Title, "Indkøb")
+	}
+
+	if _, err := ParseHeaderProfiles([]byte(`[{"locale": "da", "back": "Tilbage", "created": "Oprettet"}]`)); err == nil {
+		t.Error("got no error for a profile without a modified label, want error")
+	}
+}
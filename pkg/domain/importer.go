@@ -0,0 +1,151 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+)
+
+// Importer defines the required behaviour for importing Notes from a note-app export
+type Importer interface {
+	// Sources returns the source of each Note within the export at the provided path
+	Sources(path string) ([]string, error)
+	// Import imports a single Note from the provided source
+	Import(source string) (Note, error)
+}
+
+// RawFormatDetector defines the behaviour of an Importer whose raw files have a format that must be
+// detected across all of its sources before any are imported
+type RawFormatDetector interface {
+	DetectRawFormat(sources []string) (RawFormat, error)
+}
+
+// ExportedNote represents a note as exported by a note-app, prior to being parsed as a Note
+type ExportedNote struct {
+	ID           string          // identifier of the note, which must be safe for use as a directory name
+	OriginalPath string          // full-qualified path to the file that the note was exported in
+	SourceFolder string          // name of the folder or notebook that the note was exported from
+	Title        string          // title of the note, inferred from its body if empty
+	Body         string          // body of the note
+	HTML         bool            // whether the body is html rather than plain text
+	CreatedAt    time.Time       // timestamp that the note was created
+	ModifiedAt   time.Time       // timestamp that the note was last modified
+	Checklist    []ChecklistItem // checklist items that were exported separately from the body
+	Attachments  []Attachment    // files that were exported alongside the note
+	Starred      bool            // whether the note was starred
+	Archived     bool            // whether the note was archived
+	Pinned       bool            // whether the note was pinned
+}
+
+// ParseFromExportedNote parses a Note from the provided ExportedNote, converting its body using the configured ContentMode
+func (ns *NoteService) ParseFromExportedNote(e ExportedNote) (Note, error) {
+	n := Note{
+		ID:           e.ID,
+		OriginalPath: e.OriginalPath,
+		SourceFolder: e.SourceFolder,
+		Title:        strings.TrimSpace(e.Title),
+		ContentMode:  ns.mode,
+		Attachments:  e.Attachments,
+		Starred:      e.Starred,
+		Archived:     e.Archived,
+		Pinned:       e.Pinned,
+	}
+
+	var err error
+	if n.Warnings, err = exportedTimestamps(e, ns.ts.loc, &n.CreatedAt, &n.ModifiedAt); err != nil {
+		return Note{}, err
+	}
+
+	// plain text is used to infer a title, regardless of the content mode
+	text := strings.Trim(e.Body, " \n") + "\n"
+
+	if e.HTML {
+		body, attachments, err := extractInlineAttachments(markCheckboxes(e.Body), AttachmentDir(e.SourceFolder, e.ID))
+		if err != nil {
+			return Note{}, err
+		}
+		n.Attachments = append(n.Attachments, attachments...)
+
+		if text, err = sanitiseInput(body); err != nil {
+			return Note{}, err
+		}
+
+		switch ns.mode {
+		case MarkdownContent:
+			md, err := htmlToMarkdown(body)
+			if err != nil {
+				return Note{}, err
+			}
+			n.Content = fmt.Sprintf("%s\n", strings.Trim(md, " \n"))
+		default:
+			n.Content = text
+		}
+	} else {
+		n.Content = text
+	}
+
+	var checklist []ChecklistItem
+	checklist, n.Content = parseChecklist(n.Content, ns.mode)
+	n.Checklist = append(e.Checklist, checklist...)
+
+	if n.Title == "" {
+		n.Title = inferTitle(text)
+		if n.Title == "" && len(n.Checklist) > 0 {
+			n.Title = inferTitle(n.Checklist[0].Text)
+		}
+		n.TitleInferred = n.Title != ""
+	}
+
+	ns.applyRules(&n)
+
+	var src string
+	if e.HTML {
+		src = e.Body
+	}
+	extractMetadata(&n, src, ns.region)
+
+	return n, nil
+}
+
+// exportedTimestamps sets the created and modified dates from the provided ExportedNote in the provided location
+//
+// Where only one of the timestamps is present, it is used for both and a warning is returned.
+func exportedTimestamps(e ExportedNote, loc *time.Location, created, modified *time.Time) ([]string, error) {
+	*created, *modified = e.CreatedAt.In(loc), e.ModifiedAt.In(loc)
+
+	switch {
+	case e.CreatedAt.IsZero() && e.ModifiedAt.IsZero():
+		return nil, errors.New("cannot locate created or modified timestamp")
+	case e.CreatedAt.IsZero():
+		*created = *modified
+		return []string{"cannot locate created timestamp, using modified timestamp"}, nil
+	case e.ModifiedAt.IsZero():
+		*modified = *created
+		return []string{"cannot locate modified timestamp, using created timestamp"}, nil
+	}
+
+	return nil, nil
+}
+
+// ParseAttachmentFromFile parses an Attachment of the Note with the provided source folder and id from the file
+// at the provided path
+func (ns *NoteService) ParseAttachmentFromFile(p, folder, id string) (Attachment, error) {
+	info, err := ns.fs.Stat(p)
+	if err != nil {
+		return Attachment{}, err
+	}
+
+	mimeType, err := ns.detectMimeType(p)
+	if err != nil {
+		return Attachment{}, fmt.Errorf("cannot detect mime type of %s: %w", p, err)
+	}
+
+	return Attachment{
+		Path:       path.Join(AttachmentDir(folder, id), info.Name()),
+		MimeType:   mimeType,
+		Size:       info.Size(),
+		SourcePath: p,
+	}, nil
+}
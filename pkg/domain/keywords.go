@@ -0,0 +1,204 @@
+package domain
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxKeywords defines the maximum number of keywords that are extracted from a single Note
+const maxKeywords = 5
+
+// minKeywordLen and maxKeywordLen define the number of characters that a keyword may contain
+const (
+	minKeywordLen = 3
+	maxKeywordLen = 30
+)
+
+// minBigramKeywordLen defines the number of characters that a keyword of a script written without spaces may contain,
+// being a character bigram
+const minBigramKeywordLen = 2
+
+// bigramScripts defines the scripts that are written without spaces between words, which are segmented into
+// overlapping character bigrams as words cannot be distinguished without a dictionary
+var bigramScripts = []*unicode.RangeTable{unicode.Han, unicode.Hiragana, unicode.Katakana}
+
+// unsegmentedScripts defines the scripts that are written without spaces between words, whose characters do not
+// convey enough meaning as bigrams, which are excluded from keywords
+var unsegmentedScripts = []*unicode.RangeTable{unicode.Thai, unicode.Lao, unicode.Khmer, unicode.Myanmar}
+
+// keywordTokenRgx matches a single word within plain text, including any inner apostrophes and hyphens
+var keywordTokenRgx = regexp.MustCompile(`[\p{L}\p{N}]+(?:['’-][\p{L}\p{N}]+)*`)
+
+// ExtractKeywords sets the keywords of each of the provided Notes, scored by tf-idf across all of the provided Notes
+//
+// The stopwords of each note's language, which is detected from the stopwords that it contains, are excluded.
+// Keywords are only suggestions for categorising notes, and are not stored, since they depend upon the notes
+// that are loaded alongside.
+func (ns *NoteService) ExtractKeywords(notes []Note) []Note {
+	terms := make([]map[string]int, len(notes))
+	docFreq := make(map[string]int)
+
+	for idx, n := range notes {
+		terms[idx] = ns.keywordTerms(n)
+		for t := range terms[idx] {
+			docFreq[t]++
+		}
+	}
+
+	var extracted []Note
+
+	for idx, n := range notes {
+		n.Keywords = topKeywords(terms[idx], docFreq, len(notes))
+		extracted = append(extracted, n)
+	}
+
+	return extracted
+}
+
+// keywordTerms returns the number of occurrences of each candidate keyword within the title, content and checklist
+// of the provided Note
+func (ns *NoteService) keywordTerms(n Note) map[string]int {
+	lines := []string{n.Title, n.Content}
+	for _, item := range n.DetachedChecklist() {
+		lines = append(lines, item.Text)
+	}
+
+	text := strings.ToLower(strings.Join(lines, "\n"))
+	text = linkRgx.ReplaceAllString(text, " ")
+	text = emailRgx.ReplaceAllString(text, " ")
+
+	var words []string
+	for _, w := range keywordTokenRgx.FindAllString(text, -1) {
+		words = append(words, segmentWord(w)...)
+	}
+
+	// exclude the stopwords of every language if the note's language cannot be detected
+	lists := ns.stopwords
+	if lang := ns.stopwords.detectLanguage(words); lang != "" {
+		lists = StopwordLists{lang: ns.stopwords[lang]}
+	}
+
+	terms := make(map[string]int)
+
+	for _, w := range words {
+		if !isKeywordCandidate(w) || lists.contains(w) {
+			continue
+		}
+		terms[w]++
+	}
+
+	return terms
+}
+
+// segmentWord returns the terms of the provided word, where each run of characters of a script that is written without
+// spaces is separated from the rest of the word, then either segmented into overlapping character bigrams or excluded
+func segmentWord(w string) []string {
+	var terms []string
+	var run []rune
+	var runScript *unicode.RangeTable
+
+	flush := func() {
+		switch {
+		case len(run) == 0:
+		case runScript == nil:
+			terms = append(terms, string(run))
+		case unicode.In(run[0], bigramScripts...):
+			if len(run) <= minBigramKeywordLen {
+				terms = append(terms, string(run))
+				break
+			}
+			for idx := 0; idx+minBigramKeywordLen <= len(run); idx++ {
+				terms = append(terms, string(run[idx:idx+minBigramKeywordLen]))
+			}
+		}
+		run = nil
+	}
+
+	for _, r := range w {
+		if script := spacelessScript(r); script != runScript {
+			flush()
+			runScript = script
+		}
+		run = append(run, r)
+	}
+	flush()
+
+	return terms
+}
+
+// spacelessScript returns the script of the provided character if it is written without spaces between words,
+// otherwise nil
+func spacelessScript(r rune) *unicode.RangeTable {
+	for _, scripts := range [][]*unicode.RangeTable{bigramScripts, unsegmentedScripts} {
+		for _, script := range scripts {
+			if unicode.Is(script, r) {
+				return script
+			}
+		}
+	}
+
+	return nil
+}
+
+// isKeywordCandidate returns true if the provided word is of a suitable length and is not a number
+//
+// Character bigrams of scripts written without spaces are suitable despite being shorter than other words.
+func isKeywordCandidate(w string) bool {
+	minLen := minKeywordLen
+	if r, _ := utf8.DecodeRuneInString(w); unicode.In(r, bigramScripts...) {
+		minLen = minBigramKeywordLen
+	}
+
+	if l := utf8.RuneCountInString(w); l < minLen || l > maxKeywordLen {
+		return false
+	}
+
+	for _, r := range w {
+		if unicode.IsLetter(r) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// topKeywords returns the highest scoring of the provided terms, which occur within the provided number of
+// documents of a corpus of the provided size
+func topKeywords(terms map[string]int, docFreq map[string]int, docs int) []string {
+	type scored struct {
+		term  string
+		score float64
+	}
+
+	var total int
+	for _, count := range terms {
+		total += count
+	}
+
+	var candidates []scored
+
+	for t, count := range terms {
+		tf := float64(count) / float64(total)
+		idf := math.Log(float64(1+docs)/float64(1+docFreq[t])) + 1
+		candidates = append(candidates, scored{term: t, score: tf * idf})
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].score != candidates[j].score {
+			return candidates[i].score > candidates[j].score
+		}
+		return candidates[i].term < candidates[j].term
+	})
+
+	var keywords []string
+
+	for idx := 0; idx < len(candidates) && idx < maxKeywords; idx++ {
+		keywords = append(keywords, candidates[idx].term)
+	}
+
+	return keywords
+}
@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSegmentWord(t *testing.T) {
+	tt := []struct {
+		name string
+		inp  string
+		want []string
+	}{
+		{
+			name: "spaced words are unchanged",
+			inp:  "garden",
+			want: []string{"garden"},
+		},
+		{
+			name: "chinese is segmented into bigrams",
+			inp:  "会议记录",
+			want: []string{"会议", "议记", "记录"},
+		},
+		{
+			name: "short chinese runs are kept whole",
+			inp:  "会议",
+			want: []string{"会议"},
+		},
+		{
+			name: "japanese is segmented at each change of script",
+			inp:  "日本語のメモ",
+			want: []string{"日本", "本語", "の", "メモ"},
+		},
+		{
+			name: "latin and chinese runs are separated",
+			inp:  "iphone会议记录",
+			want: []string{"iphone", "会议", "议记", "记录"},
+		},
+		{
+			name: "korean is written with spaces so is unchanged",
+			inp:  "회의록",
+			want: []string{"회의록"},
+		},
+		{
+			name: "thai is excluded",
+			inp:  "สวัสดีครับ",
+			want: nil,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := segmentWord(tc.inp); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestExtractKeywords_CJK(t *testing.T) {
+	ns := NewNoteService(nil)
+
+	notes := ns.ExtractKeywords([]Note{
+		{Title: "会议记录", Content: "会议记录 会议 预算\n"},
+		{Title: "Shopping", Content: "garden seeds\n"},
+	})
+
+	want := []string{"会议", "议记", "记录", "预算"}
+	if got := notes[0].Keywords; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
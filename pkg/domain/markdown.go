@@ -0,0 +1,467 @@
+package domain
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// mdEscaper escapes characters that would otherwise be interpreted as inline markdown,
+// or as html tags and entities within it
+var mdEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"&", `\&`,
+	"<", `\<`,
+	"*", `\*`,
+	"_", `\_`,
+	"`", "\\`",
+	"[", `\[`,
+	"]", `\]`,
+)
+
+// mdLineStartRgx matches text that would otherwise be interpreted as a markdown block when it begins a line
+var mdLineStartRgx = regexp.MustCompile(`^(#|>|-|\+)`)
+
+// mdOrderedRgx matches the number of text that would otherwise be interpreted as an ordered list item
+// when it begins a line
+var mdOrderedRgx = regexp.MustCompile(`^\d+[.)]`)
+
+// mdWhitespaceRgx matches runs of html whitespace
+var mdWhitespaceRgx = regexp.MustCompile(`[ \t\r\n\f]+`)
+
+// mdBlankLinesRgx matches runs of more than one blank line
+var mdBlankLinesRgx = regexp.MustCompile(`\n{3,}`)
+
+// htmlToMarkdown converts the provided html document into CommonMark (with tables)
+func htmlToMarkdown(inp string) (string, error) {
+	doc, err := html.Parse(strings.NewReader(inp))
+	if err != nil {
+		return "", fmt.Errorf("cannot parse html: %w", err)
+	}
+
+	w := &mdWriter{}
+	w.node(doc)
+
+	return tidyMarkdown(w.sb.String()), nil
+}
+
+// mdWriter accumulates markdown rendered from html nodes
+type mdWriter struct {
+	sb          strings.Builder
+	prefixes    []string // line prefixes of enclosing containers (blockquotes and list items)
+	opened      int      // number of containers opened since content was last written
+	pending     int      // newlines required before next content
+	hard        bool     // whether a pending single newline is a hard line break
+	space       bool     // whether collapsed whitespace is pending before next content
+	atLineStart bool     // whether next content begins a line
+	started     bool     // whether any content has been written
+}
+
+// node renders the provided html node and its children
+func (w *mdWriter) node(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		w.text(n.Data)
+		return
+	case html.DocumentNode:
+		w.children(n)
+		return
+	case html.ElementNode:
+	default:
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.Head, atom.Script, atom.Style, atom.Title:
+		// not part of visible content
+	case atom.Br:
+		w.lineBreak(1, true)
+	case atom.Div:
+		w.lineBreak(1, true)
+		w.children(n)
+		w.lineBreak(1, true)
+	case atom.P:
+		w.lineBreak(2, false)
+		w.children(n)
+		w.lineBreak(2, false)
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		w.heading(n)
+	case atom.B, atom.Strong:
+		w.wrapInline(n, "**")
+	case atom.I, atom.Em:
+		w.wrapInline(n, "*")
+	case atom.S, atom.Strike, atom.Del:
+		w.wrapInline(n, "~~")
+	case atom.Code:
+		w.code(n)
+	case atom.A:
+		w.link(n)
+	case atom.Img:
+		w.image(n)
+	case atom.Ul, atom.Ol:
+		w.list(n)
+	case atom.Blockquote:
+		w.lineBreak(2, false)
+		w.prefixes = append(w.prefixes, "> ")
+		w.opened++
+		w.children(n)
+		w.prefixes = w.prefixes[:len(w.prefixes)-1]
+		if w.opened > 0 {
+			w.opened--
+		}
+		w.lineBreak(2, false)
+	case atom.Pre:
+		w.pre(n)
+	case atom.Hr:
+		w.lineBreak(2, false)
+		w.raw("---")
+		w.lineBreak(2, false)
+	case atom.Table:
+		w.table(n)
+	default:
+		w.children(n)
+	}
+}
+
+// children renders each of the provided html node's children
+func (w *mdWriter) children(n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		w.node(c)
+	}
+}
+
+// text renders the provided text content with whitespace collapsed and markdown characters escaped
+func (w *mdWriter) text(s string) {
+	collapsed := mdWhitespaceRgx.ReplaceAllString(s, " ")
+	if collapsed == "" {
+		return
+	}
+
+	if collapsed == " " {
+		w.space = true
+		return
+	}
+
+	leading := strings.HasPrefix(collapsed, " ")
+	trailing := strings.HasSuffix(collapsed, " ")
+	collapsed = mdEscaper.Replace(strings.TrimSpace(collapsed))
+
+	w.space = w.space || leading
+	w.flush()
+	if w.atLineStart {
+		collapsed = escapeLineStart(collapsed)
+	}
+	w.raw(collapsed)
+	w.space = trailing
+}
+
+// raw renders the provided markdown as-is, applying container prefixes to any new lines
+func (w *mdWriter) raw(s string) {
+	w.flush()
+
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		if i > 0 {
+			w.newline(false)
+		}
+		w.sb.WriteString(l)
+	}
+
+	w.atLineStart = false
+	w.started = true
+	w.opened = 0
+}
+
+// flush writes any pending line breaks or whitespace ahead of new content
+func (w *mdWriter) flush() {
+	if !w.started {
+		w.sb.WriteString(strings.Join(w.prefixes, ""))
+		w.atLineStart = true
+	}
+
+	if w.pending > 0 {
+		// blank lines preceding content belong to the containers that were already open
+		outer := strings.Join(w.prefixes[:len(w.prefixes)-w.opened], "")
+		for i := 1; i < w.pending; i++ {
+			w.sb.WriteString("\n" + outer)
+		}
+		w.newline(w.hard && w.pending == 1)
+		w.pending = 0
+		w.hard = false
+		w.space = false
+		w.atLineStart = true
+	}
+
+	if w.space && !w.atLineStart && w.started {
+		w.sb.WriteString(" ")
+	}
+	w.space = false
+}
+
+// newline writes a new line followed by the current container prefixes
+func (w *mdWriter) newline(hard bool) {
+	if hard {
+		w.sb.WriteString("  ")
+	}
+	w.sb.WriteString("\n")
+	w.sb.WriteString(strings.Join(w.prefixes, ""))
+}
+
+// lineBreak requests that the provided number of newlines precede the next content
+func (w *mdWriter) lineBreak(n int, hard bool) {
+	if !w.started {
+		return
+	}
+
+	if n > w.pending {
+		w.pending = n
+		w.hard = hard
+	}
+}
+
+// inline renders the children of the provided html node in isolation and returns the result
+func (w *mdWriter) inline(n *html.Node) string {
+	sub := &mdWriter{}
+	sub.children(n)
+	return strings.TrimSpace(sub.sb.String())
+}
+
+// wrapInline renders the children of the provided html node wrapped with the provided delimiter
+func (w *mdWriter) wrapInline(n *html.Node, delim string) {
+	content := w.inline(n)
+	if content == "" {
+		return
+	}
+
+	w.space = w.space || hasLeadingSpace(n)
+	w.raw(delim + content + delim)
+	w.space = hasTrailingSpace(n)
+}
+
+// heading renders the provided html heading node
+func (w *mdWriter) heading(n *html.Node) {
+	level := int(n.Data[1] - '0')
+	content := strings.ReplaceAll(w.inline(n), "\n", " ")
+	if content == "" {
+		return
+	}
+
+	w.lineBreak(2, false)
+	w.raw(strings.Repeat("#", level) + " " + content)
+	w.lineBreak(2, false)
+}
+
+// code renders the provided html inline code node
+func (w *mdWriter) code(n *html.Node) {
+	content := strings.TrimSpace(mdWhitespaceRgx.ReplaceAllString(textContent(n), " "))
+	if content == "" {
+		return
+	}
+
+	delim := "`"
+	if strings.Contains(content, "`") {
+		delim = "``"
+	}
+
+	w.raw(fmt.Sprintf("%s%s%s", delim, content, delim))
+}
+
+// link renders the provided html anchor node
+func (w *mdWriter) link(n *html.Node) {
+	content := w.inline(n)
+	href := attr(n, "href")
+
+	if href == "" || strings.HasPrefix(href, "#") || strings.HasPrefix(href, "javascript:") {
+		if content != "" {
+			w.raw(content)
+		}
+		return
+	}
+
+	if content == "" {
+		content = mdEscaper.Replace(href)
+	}
+
+	w.raw(fmt.Sprintf("[%s](%s)", content, escapeDestination(href)))
+}
+
+// image renders the provided html image node
+func (w *mdWriter) image(n *html.Node) {
+	src := attr(n, "src")
+	if src == "" {
+		return
+	}
+
+	w.raw(fmt.Sprintf("![%s](%s)", mdEscaper.Replace(attr(n, "alt")), escapeDestination(src)))
+}
+
+// list renders the provided html list node
+func (w *mdWriter) list(n *html.Node) {
+	w.lineBreak(1, false)
+	if len(w.prefixes) == 0 {
+		w.lineBreak(2, false)
+	}
+
+	num := 1
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode || c.DataAtom != atom.Li {
+			continue
+		}
+
+		marker := "- "
+		if n.DataAtom == atom.Ol {
+			marker = fmt.Sprintf("%d. ", num)
+			num++
+		}
+
+		w.lineBreak(1, false)
+		w.raw(marker)
+		w.atLineStart = true
+		w.prefixes = append(w.prefixes, strings.Repeat(" ", len(marker)))
+		w.children(c)
+		w.prefixes = w.prefixes[:len(w.prefixes)-1]
+		w.pending, w.hard = 0, false
+	}
+
+	w.lineBreak(1, false)
+	if len(w.prefixes) == 0 {
+		w.lineBreak(2, false)
+	}
+}
+
+// pre renders the provided html preformatted node as a fenced code block
+func (w *mdWriter) pre(n *html.Node) {
+	content := strings.Trim(textContent(n), "\n")
+
+	w.lineBreak(2, false)
+	w.raw("```\n" + content + "\n```")
+	w.lineBreak(2, false)
+}
+
+// table renders the provided html table node, treating its first row as the header
+func (w *mdWriter) table(n *html.Node) {
+	var rows [][]string
+	cols := 0
+
+	var walk func(*html.Node)
+	walk = func(c *html.Node) {
+		if c.Type == html.ElementNode && c.DataAtom == atom.Tr {
+			var row []string
+			for cell := c.FirstChild; cell != nil; cell = cell.NextSibling {
+				if cell.Type == html.ElementNode && (cell.DataAtom == atom.Td || cell.DataAtom == atom.Th) {
+					content := strings.ReplaceAll(w.inline(cell), "\n", " ")
+					content = strings.ReplaceAll(content, "|", `\|`)
+					row = append(row, strings.TrimSpace(strings.TrimSuffix(content, "  ")))
+				}
+			}
+			if len(row) > cols {
+				cols = len(row)
+			}
+			rows = append(rows, row)
+			return
+		}
+		for cc := c.FirstChild; cc != nil; cc = cc.NextSibling {
+			walk(cc)
+		}
+	}
+	walk(n)
+
+	if len(rows) == 0 || cols == 0 {
+		return
+	}
+
+	var lines []string
+	for i, row := range rows {
+		for len(row) < cols {
+			row = append(row, "")
+		}
+		lines = append(lines, "| "+strings.Join(row, " | ")+" |")
+		if i == 0 {
+			lines = append(lines, "|"+strings.Repeat(" --- |", cols))
+		}
+	}
+
+	w.lineBreak(2, false)
+	w.raw(strings.Join(lines, "\n"))
+	w.lineBreak(2, false)
+}
+
+// escapeLineStart escapes the provided text so that it is not interpreted as a markdown block when it begins a line
+//
+// A backslash before a digit is not an escape, so the delimiter of an ordered list item is escaped instead.
+func escapeLineStart(s string) string {
+	if loc := mdOrderedRgx.FindStringIndex(s); loc != nil {
+		return s[:loc[1]-1] + `\` + s[loc[1]-1:]
+	}
+
+	if mdLineStartRgx.MatchString(s) {
+		return `\` + s
+	}
+
+	return s
+}
+
+// tidyMarkdown removes redundant whitespace from the provided markdown
+func tidyMarkdown(md string) string {
+	lines := strings.Split(md, "\n")
+
+	for i, l := range lines {
+		trimmed := strings.TrimRight(l, " ")
+		// hard line breaks are only retained where followed by further content
+		if strings.HasSuffix(l, "  ") && trimmed != "" && i+1 < len(lines) && strings.TrimSpace(lines[i+1]) != "" {
+			trimmed += "  "
+		}
+		lines[i] = trimmed
+	}
+
+	md = strings.Join(lines, "\n")
+	md = mdBlankLinesRgx.ReplaceAllString(md, "\n\n")
+
+	return strings.Trim(md, "\n")
+}
+
+// textContent returns the concatenated text of the provided html node and its descendants
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+
+	var sb strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		sb.WriteString(textContent(c))
+	}
+	return sb.String()
+}
+
+// attr returns the value of the provided attribute key on the provided html node
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// escapeDestination escapes the provided link destination for use within markdown
+func escapeDestination(dest string) string {
+	if strings.ContainsAny(dest, " ()<>") {
+		return "<" + strings.NewReplacer("<", "%3C", ">", "%3E").Replace(dest) + ">"
+	}
+	return dest
+}
+
+// hasLeadingSpace returns true if the provided html node's text content begins with whitespace
+func hasLeadingSpace(n *html.Node) bool {
+	t := textContent(n)
+	return t != "" && strings.TrimLeft(t, " \t\r\n\f") != t
+}
+
+// hasTrailingSpace returns true if the provided html node's text content ends with whitespace
+func hasTrailingSpace(n *html.Node) bool {
+	t := textContent(n)
+	return t != "" && strings.TrimRight(t, " \t\r\n\f") != t
+}
@@ -0,0 +1,104 @@
+package domain
+
+import "testing"
+
+func TestHTMLToMarkdown(t *testing.T) {
+	tt := []struct {
+		name string
+		inp  string
+		want string
+	}{
+		{
+			name: "nested lists are indented by their parent's marker",
+			inp:  "<ul><li>one<ul><li>two<ol><li>three</li></ol></li></ul></li><li>four</li></ul>",
+			want: "- one\n  - two\n    1. three\n- four",
+		},
+		{
+			name: "ordered lists are numbered",
+			inp:  "<ol><li>first</li><li>second<ul><li>nested</li></ul></li></ol>",
+			want: "1. first\n2. second\n   - nested",
+		},
+		{
+			name: "emphasis and strong are delimited",
+			inp:  "<p><b>bold</b> and <i>italic</i> and <strong><em>both</em></strong></p>",
+			want: "**bold** and *italic* and ***both***",
+		},
+		{
+			name: "spaces within emphasis are moved outside of its delimiters",
+			inp:  "<p>a <b> spaced </b> word</p>",
+			want: "a **spaced** word",
+		},
+		{
+			name: "links keep their text and destination",
+			inp:  `<p>see <a href="https://example.com/a_b">the site</a></p>`,
+			want: "see [the site](https://example.com/a_b)",
+		},
+		{
+			name: "link destinations with spaces are enclosed",
+			inp:  `<p><a href="https://example.com/x y">spaced</a></p>`,
+			want: "[spaced](<https://example.com/x y>)",
+		},
+		{
+			name: "tables have a header row and escaped pipes",
+			inp:  "<table><tr><th>Item</th><th>Qty</th></tr><tr><td>eggs</td><td>12</td></tr><tr><td>a|b</td><td></td></tr></table>",
+			want: "| Item | Qty |\n| --- | --- |\n| eggs | 12 |\n| a\\|b |  |",
+		},
+		{
+			name: "inline metacharacters are escaped",
+			inp:  "<p>2*3 = 6_ish [not a link] `code` \\ back</p>",
+			want: "2\\*3 = 6\\_ish \\[not a link\\] \\`code\\` \\\\ back",
+		},
+		{
+			name: "decoded entities are not interpreted as html",
+			inp:  "<p>&lt;script&gt;alert(1)&lt;/script&gt; &amp;quot;quoted&amp;quot; AT&amp;T</p>",
+			want: "\\<script>alert(1)\\</script> \\&quot;quoted\\&quot; AT\\&T",
+		},
+		{
+			name: "decoded entities within link text and image alt text are escaped",
+			inp:  `<p><a href="https://example.com">&lt;b&gt; &amp;amp;</a> <img src="a.png" alt="&lt;i&gt;"></p>`,
+			want: "[\\<b> \\&amp;](https://example.com) ![\\<i>](a.png)",
+		},
+		{
+			name: "block markers that begin a line are escaped",
+			inp:  "<p># not a heading</p><p>- not a list</p><p>12. not ordered</p><p>> not a quote</p>",
+			want: "\\# not a heading\n\n\\- not a list\n\n12\\. not ordered\n\n\\> not a quote",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := htmlToMarkdown(tc.inp)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseFromRawFile_Markdown(t *testing.T) {
+	fs := &memFileSystem{files: map[string][]byte{
+		"/export/Other/101/content.html": []byte(`<html><body><div><a href="#">Back</a> Shopping</div><br><br>` +
+			`Create Time: 25/03/2021 10:00<br>Modify Time: 26/03/2021 11:30<br><br>` +
+			`<p>Buy <b>eggs</b></p><ul><li>milk</li></ul></body></html>`),
+	}}
+
+	ns := NewNoteService(fs, WithContentMode(MarkdownContent))
+
+	n, err := ns.ParseFromRawFile("/export/Other/101/content.html", "Other", "101")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// header is stripped, leaving only the content that follows the timestamps
+	if want := "Buy **eggs**\n\n- milk\n"; n.Content != want {
+		t.Errorf("got content %q, want %q", n.Content, want)
+	}
+
+	if n.Title != "Shopping" {
+		t.Errorf("got title %q, want %q", n.Title, "Shopping")
+	}
+}
@@ -11,16 +11,103 @@ import (
 
 const maxFnameTitleLen = 30
 
+// maxInferredTitleLen defines the maximum number of characters of a title that is inferred from note content
+const maxInferredTitleLen = 60
+
+// TimestampKind defines which of a Note's timestamps it is dated by
+type TimestampKind string
+
+const (
+	// CreatedTimestamp dates a Note by the time it was created
+	CreatedTimestamp TimestampKind = "created"
+	// ModifiedTimestamp dates a Note by the time it was last modified
+	ModifiedTimestamp TimestampKind = "modified"
+)
+
+// ParseTimestampKind returns the TimestampKind represented by the provided string
+func ParseTimestampKind(s string) (TimestampKind, error) {
+	switch k := TimestampKind(s); k {
+	case CreatedTimestamp, ModifiedTimestamp:
+		return k, nil
+	}
+
+	return "", fmt.Errorf("invalid timestamp kind: %s", s)
+}
+
+// ContentMode defines the format that a Note's content is converted to from its raw source
+type ContentMode string
+
+const (
+	// PlainTextContent converts raw content to plain text, discarding any formatting
+	PlainTextContent ContentMode = "text"
+	// MarkdownContent converts raw content to markdown, retaining formatting such as lists, emphasis, links and tables
+	MarkdownContent ContentMode = "markdown"
+)
+
+// ParseContentMode returns the ContentMode represented by the provided string
+func ParseContentMode(s string) (ContentMode, error) {
+	switch m := ContentMode(s); m {
+	case PlainTextContent, MarkdownContent:
+		return m, nil
+	}
+
+	return "", fmt.Errorf("invalid content mode: %s", s)
+}
+
+// ChecklistItem represents a single item of a checklist Note
+type ChecklistItem struct {
+	Text string `json:"text"` // text of the item
+	Done bool   `json:"done"` // whether the item has been checked off
+}
+
+// Attachment represents a file that accompanies a Note
+type Attachment struct {
+	Path       string `json:"path"`     // path to attachment relative to the directory of the note
+	MimeType   string `json:"mimeType"` // mime type of the attachment
+	Size       int64  `json:"size"`     // size of the attachment in bytes
+	SourcePath string `json:"-"`        // full-qualified path to original attachment file (inflated, not stored)
+	Data       []byte `json:"-"`        // content of an attachment that was inlined within the note source (inflated, not stored)
+}
+
 // Note represents a single Note
 type Note struct {
-	ID           string    `json:"id"`           // numeric gnotes id
-	Index        int       `json:"-"`            // index of note within a slice
-	ParentDir    string    `json:"-"`            // parent directory of note once cleaned (inflated, not stored)
-	Category     string    `json:"-"`            // category of note (inflated, not stored)
-	OriginalPath string    `json:"originalPath"` // original full-qualified path to note html source file
-	Title        string    `json:"title"`        // title of the note
-	Timestamp    time.Time `json:"timestamp"`    // timestamp of the note
-	Content      string    `json:"content"`      // content of the note
+	ID             string          `json:"id"`                       // numeric gnotes id
+	ParentDir      string          `json:"-"`                        // parent directory of note once cleaned (inflated, not stored)
+	Category       string          `json:"-"`                        // category of note (inflated, not stored)
+	Tags           []string        `json:"-"`                        // tags of note, in addition to its category (inflated, not stored)
+	OutPath        string          `json:"-"`                        // path of note relative to its parent directory, excluding its extension (inflated, not stored)
+	OriginalPath   string          `json:"originalPath"`             // original full-qualified path to note html source file
+	SourceFolder   string          `json:"sourceFolder"`             // name of the export folder that the note was found in
+	Encoding       string          `json:"encoding,omitempty"`       // encoding of the original html source file
+	Title          string          `json:"title"`                    // title of the note, as written
+	Slug           string          `json:"slug"`                     // filename-safe representation of the title
+	FilenameSuffix string          `json:"filenameSuffix,omitempty"` // disambiguates the filename of the note from another with the same date and slug
+	TitleInferred  bool            `json:"titleInferred,omitempty"`  // whether the title was inferred from content
+	Starred        bool            `json:"starred,omitempty"`        // whether the note is starred
+	Archived       bool            `json:"archived,omitempty"`       // whether the note is archived
+	Pinned         bool            `json:"pinned,omitempty"`         // whether the note is pinned
+	CreatedAt      time.Time       `json:"createdAt"`                // timestamp that the note was created
+	ModifiedAt     time.Time       `json:"modifiedAt"`               // timestamp that the note was last modified
+	DateBy         TimestampKind   `json:"dateBy"`                   // timestamp that the note is dated by
+	Content        string          `json:"content"`                  // content of the note
+	ContentMode    ContentMode     `json:"contentMode"`              // format of the note content
+	Checklist      []ChecklistItem `json:"checklist,omitempty"`      // checklist items of the note (also rendered in place within content)
+	Attachments    []Attachment    `json:"attachments,omitempty"`    // files that accompany the note
+	Links          []string        `json:"links,omitempty"`          // web links found within the note
+	Emails         []string        `json:"emails,omitempty"`         // email addresses found within the note
+	Phones         []string        `json:"phones,omitempty"`         // phone numbers found within the note, in E.164 format
+	Hashtags       []string        `json:"hashtags,omitempty"`       // hashtags found within the note, excluding the hash
+	Keywords       []string        `json:"-"`                        // most significant words of the note, relative to the notes it was loaded with (inflated, not stored)
+	Warnings       []string        `json:"warnings,omitempty"`       // recoverable problems encountered while parsing the note
+	RawHTML        string          `json:"rawHtml,omitempty"`        // raw html source of the note, decoded as utf-8 (only retained if embedded)
+}
+
+// Timestamp returns the timestamp that the Note is dated by, defaulting to its created timestamp
+func (n Note) Timestamp() time.Time {
+	if n.DateBy == ModifiedTimestamp {
+		return n.ModifiedAt
+	}
+	return n.CreatedAt
 }
 
 // MarshalJSON implements custom marshaler on Note struct
@@ -37,21 +124,113 @@ func (n *Note) MarshalJSON() ([]byte, error) {
 	return json.Marshal(payload)
 }
 
+// UnmarshalJSON implements custom unmarshaler on Note struct
+func (n *Note) UnmarshalJSON(b []byte) error {
+	type noteAlias Note
+	var payload = struct {
+		Timestamp *time.Time `json:"timestamp"` // legacy payloads only stored the modified timestamp
+		*noteAlias
+	}{
+		noteAlias: (*noteAlias)(n),
+	}
+
+	if err := json.Unmarshal(b, &payload); err != nil {
+		return err
+	}
+
+	// date legacy notes by their modified timestamp so that existing filenames are retained
+	if payload.Timestamp != nil && n.ModifiedAt.IsZero() {
+		n.ModifiedAt = *payload.Timestamp
+		n.DateBy = ModifiedTimestamp
+	}
+
+	return nil
+}
+
 // Filename returns a generated filename
 func (n Note) Filename() string {
-	title := strings.ToLower(n.Title)
-	baseName := sanitize.BaseName(title)
-	fileName := fmt.Sprintf("%s_%s", n.Timestamp.Format("2006-01-02"), baseName)
-	if len(fileName) > maxFnameTitleLen {
-		fileName = fmt.Sprintf("%s__", fileName[:maxFnameTitleLen])
+	slug := n.Slug
+	if slug == "" {
+		// notes cleaned before slugs were stored
+		slug = Slugify(n.Title)
+	}
+
+	fileName := fmt.Sprintf("%s_%s", n.Timestamp().Format("2006-01-02"), slug)
+	// truncate by runes rather than bytes, so that a multi-byte character is never split
+	if runes := []rune(fileName); len(runes) > maxFnameTitleLen {
+		fileName = fmt.Sprintf("%s__", string(runes[:maxFnameTitleLen]))
+	}
+
+	if n.FilenameSuffix != "" {
+		fileName = fmt.Sprintf("%s_%s", fileName, n.FilenameSuffix)
 	}
+
 	return fileName
 }
 
-// NoteManifest maps a note filename to its category
+// RelPath returns the path of the Note relative to its parent directory using forward slashes, excluding its extension,
+// defaulting to its filename within a directory per category
+func (n Note) RelPath() string {
+	if n.OutPath != "" {
+		return n.OutPath
+	}
+
+	if n.Category != "" {
+		return fmt.Sprintf("%s/%s", n.Category, n.Filename())
+	}
+
+	return n.Filename()
+}
+
+// Slugify returns a filename-safe representation of the provided title
+func Slugify(title string) string {
+	return sanitize.BaseName(strings.ToLower(title))
+}
+
+// NoteManifest maps a note filename to its category, tags and flags
 type NoteManifest struct {
 	path    string
-	content map[string]string
+	content map[string]manifestEntry
+}
+
+// manifestEntry represents the category, tags and flags of a single note within a NoteManifest
+type manifestEntry struct {
+	Category string   `json:"category"`
+	Tags     []string `json:"tags,omitempty"`
+	Starred  bool     `json:"starred,omitempty"`
+	Archived bool     `json:"archived,omitempty"`
+	Pinned   bool     `json:"pinned,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler
+func (me manifestEntry) MarshalJSON() ([]byte, error) {
+	// entries without tags or flags are written as a plain category, as they were before tags were added
+	if len(me.Tags) == 0 && !me.Starred && !me.Archived && !me.Pinned {
+		return json.Marshal(me.Category)
+	}
+
+	type entryAlias manifestEntry
+	return json.Marshal(entryAlias(me))
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (me *manifestEntry) UnmarshalJSON(b []byte) error {
+	var category string
+	if err := json.Unmarshal(b, &category); err == nil {
+		*me = manifestEntry{Category: category}
+		return nil
+	}
+
+	type entryAlias manifestEntry
+	var entry entryAlias
+
+	if err := json.Unmarshal(b, &entry); err != nil {
+		return err
+	}
+
+	*me = manifestEntry(entry)
+
+	return nil
 }
 
 // Len returns count of filenames with categories
@@ -62,7 +241,7 @@ func (nm *NoteManifest) Len() int {
 // Set assigns the provided Note to the manifest
 func (nm *NoteManifest) Set(n Note) error {
 	if nm.content == nil {
-		nm.content = make(map[string]string)
+		nm.content = make(map[string]manifestEntry)
 	}
 
 	filename := n.Filename()
@@ -71,15 +250,23 @@ func (nm *NoteManifest) Set(n Note) error {
 		return fmt.Errorf("filename %s already has category", filename)
 	}
 
-	nm.content[filename] = n.Category
+	nm.content[filename] = manifestEntry{
+		Category: n.Category,
+		Tags:     n.Tags,
+		Starred:  n.Starred,
+		Archived: n.Archived,
+		Pinned:   n.Pinned,
+	}
 
 	return nil
 }
 
-// EnrichCat sets the category on the provided Note
+// EnrichCat sets the category, tags and flags on the provided Note
+//
+// Flags recorded by the manifest replace any flags that the note was cleaned with.
 func (nm *NoteManifest) EnrichCat(n *Note) {
 	if nm.content == nil {
-		nm.content = make(map[string]string)
+		nm.content = make(map[string]manifestEntry)
 	}
 
 	filename := n.Filename()
@@ -88,13 +275,18 @@ func (nm *NoteManifest) EnrichCat(n *Note) {
 		return
 	}
 
-	n.Category = nm.content[filename]
+	entry := nm.content[filename]
+	n.Category = entry.Category
+	n.Tags = ParseTags(entry.Tags...)
+	n.Starred = entry.Starred
+	n.Archived = entry.Archived
+	n.Pinned = entry.Pinned
 }
 
 // HasCat returs true if existing filename has a category
 func (nm *NoteManifest) HasCat(filename string) bool {
 	if nm.content == nil {
-		nm.content = make(map[string]string)
+		nm.content = make(map[string]manifestEntry)
 	}
 
 	_, ok := nm.content[filename]
@@ -109,7 +301,7 @@ func (nm *NoteManifest) MarshalJSON() ([]byte, error) {
 
 // UnmarshalJSON implements json.Unmarshaler
 func (nm *NoteManifest) UnmarshalJSON(b []byte) error {
-	var content map[string]string
+	var content map[string]manifestEntry
 
 	if err := json.Unmarshal(b, &content); err != nil {
 		return err
@@ -0,0 +1,178 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+)
+
+// NoteFlag defines a flag that marks a Note for particular treatment when it is stored
+type NoteFlag string
+
+const (
+	// StarredFlag marks a Note as important, listing it before other notes in indexes
+	StarredFlag NoteFlag = "starred"
+	// ArchivedFlag marks a Note as no longer in use, separating it from other notes or skipping it when stored
+	ArchivedFlag NoteFlag = "archived"
+	// PinnedFlag marks a Note as one to keep close to hand, listing it in its own index
+	PinnedFlag NoteFlag = "pinned"
+)
+
+// NoteFlags defines every NoteFlag, in order of display
+var NoteFlags = []NoteFlag{StarredFlag, ArchivedFlag, PinnedFlag}
+
+// ParseNoteFlag returns the NoteFlag represented by the provided string
+func ParseNoteFlag(s string) (NoteFlag, error) {
+	switch f := NoteFlag(s); f {
+	case StarredFlag, ArchivedFlag, PinnedFlag:
+		return f, nil
+	}
+
+	return "", fmt.Errorf("invalid note flag: %s", s)
+}
+
+// Flag returns true if the provided flag is set on the Note
+func (n Note) Flag(f NoteFlag) bool {
+	switch f {
+	case StarredFlag:
+		return n.Starred
+	case ArchivedFlag:
+		return n.Archived
+	case PinnedFlag:
+		return n.Pinned
+	}
+
+	return false
+}
+
+// SetFlag sets or clears the provided flag on the Note
+func (n *Note) SetFlag(f NoteFlag, v bool) {
+	switch f {
+	case StarredFlag:
+		n.Starred = v
+	case ArchivedFlag:
+		n.Archived = v
+	case PinnedFlag:
+		n.Pinned = v
+	}
+}
+
+// Flags returns the flags that are set on the Note, in order of display
+func (n Note) Flags() []NoteFlag {
+	var flags []NoteFlag
+
+	for _, f := range NoteFlags {
+		if n.Flag(f) {
+			flags = append(flags, f)
+		}
+	}
+
+	return flags
+}
+
+// ArchiveMode defines how archived notes are stored
+type ArchiveMode string
+
+const (
+	// SeparateArchived stores archived notes within their own directory, retaining their paths within it
+	SeparateArchived ArchiveMode = "separate"
+	// SkipArchived does not store archived notes
+	SkipArchived ArchiveMode = "skip"
+	// IncludeArchived stores archived notes alongside all other notes
+	IncludeArchived ArchiveMode = "include"
+)
+
+// archiveDir defines the name of the directory that archived notes are separated into
+const archiveDir = "_archived"
+
+// ParseArchiveMode returns the ArchiveMode represented by the provided string
+func ParseArchiveMode(s string) (ArchiveMode, error) {
+	switch m := ArchiveMode(s); m {
+	case SeparateArchived, SkipArchived, IncludeArchived:
+		return m, nil
+	}
+
+	return "", fmt.Errorf("invalid archive mode: %s", s)
+}
+
+// ApplyArchiveMode returns the provided Notes with archived notes separated or skipped according to the provided mode
+//
+// Paths are checked once archived notes have been separated, returning an error if any two notes would be written
+// to the same path regardless of case or unicode composition, or if any note would be written within a reserved name.
+func (ns *NoteService) ApplyArchiveMode(notes []Note, mode ArchiveMode) ([]Note, error) {
+	var applied []Note
+	written := make(map[string]string)
+
+	for _, n := range notes {
+		if IsReservedPath(n.RelPath()) {
+			return nil, fmt.Errorf("note with id %s has reserved path %s", n.ID, n.RelPath())
+		}
+
+		if n.Archived {
+			switch mode {
+			case SkipArchived:
+				continue
+			case SeparateArchived:
+				n.OutPath = archiveDir + "/" + n.RelPath()
+			}
+		}
+
+		key := filenameKey(n.RelPath())
+		if id, ok := written[key]; ok {
+			return nil, fmt.Errorf("notes with ids %s and %s both have path %s", id, n.ID, n.RelPath())
+		}
+
+		written[key] = n.ID
+		applied = append(applied, n)
+	}
+
+	return applied, nil
+}
+
+// sortIndexNotes sorts the provided Notes in the order that they are listed by an index,
+// with starred notes first, followed by pinned notes, then in order of path
+func sortIndexNotes(notes []Note) {
+	sort.SliceStable(notes, func(i, j int) bool {
+		n1, n2 := notes[i], notes[j]
+
+		switch {
+		case n1.Starred != n2.Starred:
+			return n1.Starred
+		case n1.Pinned != n2.Pinned:
+			return n1.Pinned
+		}
+
+		return n1.RelPath() < n2.RelPath()
+	})
+}
+
+// indexedFlags defines the flags whose notes are listed by an index of their own
+var indexedFlags = []NoteFlag{StarredFlag, PinnedFlag}
+
+// BuildFlagIndexes returns an index of the provided Notes for each of the starred and pinned flags that is set
+// on any of them, listing notes in the same order as tag indexes
+func (ns *NoteService) BuildFlagIndexes(notes []Note) []NoteIndex {
+	var indexes []NoteIndex
+
+	for _, f := range indexedFlags {
+		var flagged []Note
+		for _, n := range notes {
+			if n.Flag(f) {
+				flagged = append(flagged, n)
+			}
+		}
+
+		if len(flagged) == 0 {
+			continue
+		}
+
+		sortIndexNotes(flagged)
+		indexes = append(indexes, NoteIndex{Name: flagIndexName(f), Notes: flagged})
+	}
+
+	return indexes
+}
+
+// flagIndexName returns the name of the index of the notes with the provided flag
+func flagIndexName(f NoteFlag) string {
+	return "_" + string(f)
+}
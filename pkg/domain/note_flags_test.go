@@ -0,0 +1,72 @@
+package domain
+
+import "testing"
+
+func TestApplyArchiveMode(t *testing.T) {
+	tt := []struct {
+		name    string
+		mode    ArchiveMode
+		notes   []Note
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:  "archived notes are separated",
+			mode:  SeparateArchived,
+			notes: []Note{{ID: "1", OutPath: "work/a"}, {ID: "2", OutPath: "work/b", Archived: true}},
+			want:  []string{"work/a", "_archived/work/b"},
+		},
+		{
+			name:  "archived notes are skipped",
+			mode:  SkipArchived,
+			notes: []Note{{ID: "1", OutPath: "work/a"}, {ID: "2", OutPath: "work/b", Archived: true}},
+			want:  []string{"work/a"},
+		},
+		{
+			name:    "notes cannot be written within the archive directory",
+			mode:    IncludeArchived,
+			notes:   []Note{{ID: "1", OutPath: "_Archived/work/a"}},
+			wantErr: true,
+		},
+		{
+			name:    "notes cannot be written within an index name",
+			mode:    SeparateArchived,
+			notes:   []Note{{ID: "1", Category: "_starred"}},
+			wantErr: true,
+		},
+		{
+			name:    "notes cannot share a path regardless of case",
+			mode:    SeparateArchived,
+			notes:   []Note{{ID: "1", OutPath: "work/a"}, {ID: "2", OutPath: "Work/A"}},
+			wantErr: true,
+		},
+	}
+
+	ns := NewNoteService(nil)
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ns.ApplyArchiveMode(tc.notes, tc.mode)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if len(got) != len(tc.want) {
+				t.Fatalf("got %d notes, want %d", len(got), len(tc.want))
+			}
+
+			for idx, n := range got {
+				if p := n.RelPath(); p != tc.want[idx] {
+					t.Errorf("note %d: got path %q, want %q", idx, p, tc.want[idx])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package domain
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/nyaruka/phonenumbers"
+)
+
+// DefaultPhoneRegion defines the region that phone numbers without an international prefix are parsed in
+const DefaultPhoneRegion = "GB"
+
+// linkRgx matches a web link within plain text, including a markdown link target
+var linkRgx = regexp.MustCompile(`(?i)\b(?:https?://|www\.)[^\s<>"'()\[\]]+`)
+
+// emailRgx matches an email address within plain text
+var emailRgx = regexp.MustCompile(`(?i)\b[a-z0-9._%+-]+@[a-z0-9-]+(?:\.[a-z0-9-]+)*\.[a-z]{2,}\b`)
+
+// phoneRgx matches a candidate phone number within plain text, which is only kept if it is a valid number
+var phoneRgx = regexp.MustCompile(`\+?\(?\d[\d ().-]{5,18}\d`)
+
+// hashtagRgx matches a hashtag within plain text, which is not part of a word, link or html entity,
+// tolerating a markdown-escaped hash
+var hashtagRgx = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_&#/\\])\\?#(\p{L}[\p{L}\p{N}_-]*)`)
+
+// hrefRgx matches the target of a html link
+var hrefRgx = regexp.MustCompile(`(?i)\shref=["']([^"']+)["']`)
+
+// linkTrailingChars defines the punctuation that is trimmed from the end of a link matched within plain text
+const linkTrailingChars = ".,;:!?*_~"
+
+// minPhoneDigits and maxPhoneDigits define the number of digits that a candidate phone number may contain
+const (
+	minPhoneDigits = 7
+	maxPhoneDigits = 15
+)
+
+// ParsePhoneRegion returns the supported phone number region represented by the provided two-letter code
+func ParsePhoneRegion(s string) (string, error) {
+	region := strings.ToUpper(strings.TrimSpace(s))
+	if !phonenumbers.GetSupportedRegions()[region] {
+		return "", fmt.Errorf("invalid phone region: %s", s)
+	}
+
+	return region, nil
+}
+
+// noteMetadata represents the values that are extracted from a single Note
+type noteMetadata struct {
+	links    valueSet
+	emails   valueSet
+	phones   valueSet
+	hashtags valueSet
+}
+
+// extractMetadata sets the links, email addresses, phone numbers and hashtags of the provided Note,
+// from its title, content and checklist along with the link targets of the provided html source, if any
+//
+// Phone numbers are normalised to E.164, using the provided region for numbers without an international prefix.
+func extractMetadata(n *Note, src, region string) {
+	var m noteMetadata
+
+	for _, match := range hrefRgx.FindAllStringSubmatch(src, -1) {
+		target := html.UnescapeString(match[1])
+		lower := strings.ToLower(target)
+
+		switch {
+		case strings.HasPrefix(lower, "http://"), strings.HasPrefix(lower, "https://"):
+			m.links.add(target)
+		case strings.HasPrefix(lower, "mailto:"):
+			m.emails.add(strings.ToLower(strings.SplitN(target[len("mailto:"):], "?", 2)[0]))
+		case strings.HasPrefix(lower, "tel:"):
+			m.addPhone(target[len("tel:"):], region)
+		}
+	}
+
+	lines := []string{n.Title, n.Content}
+	for _, item := range n.DetachedChecklist() {
+		lines = append(lines, item.Text)
+	}
+	text := strings.Join(lines, "\n")
+
+	// links and email addresses are removed once matched, so that their digits are not mistaken for phone numbers
+	text = linkRgx.ReplaceAllStringFunc(text, func(link string) string {
+		m.links.add(strings.TrimRight(link, linkTrailingChars))
+		return " "
+	})
+
+	text = emailRgx.ReplaceAllStringFunc(text, func(email string) string {
+		m.emails.add(strings.ToLower(email))
+		return " "
+	})
+
+	for _, match := range hashtagRgx.FindAllStringSubmatch(text, -1) {
+		m.hashtags.add(strings.TrimRight(match[1], "-"))
+	}
+
+	for _, candidate := range phoneRgx.FindAllString(text, -1) {
+		m.addPhone(candidate, region)
+	}
+
+	n.Links = m.links.values
+	n.Emails = m.emails.values
+	n.Phones = m.phones.values
+	n.Hashtags = m.hashtags.values
+}
+
+// addPhone adds the provided candidate phone number in E.164 format if it is a valid number
+func (m *noteMetadata) addPhone(candidate, region string) {
+	digits := phonenumbers.NormalizeDigitsOnly(candidate)
+	if len(digits) < minPhoneDigits || len(digits) > maxPhoneDigits {
+		return
+	}
+
+	// numbers without an international prefix cannot be parsed without a region
+	if region == "" && !strings.HasPrefix(strings.TrimSpace(candidate), "+") {
+		return
+	}
+
+	num, err := phonenumbers.Parse(candidate, region)
+	if err != nil || !phonenumbers.IsValidNumber(num) {
+		return
+	}
+
+	m.phones.add(phonenumbers.Format(num, phonenumbers.E164))
+}
+
+// valueSet represents an ordered set of values that are unique regardless of case
+type valueSet struct {
+	values []string
+	seen   map[string]bool
+}
+
+// add appends the provided value to the set, unless it is empty or already present
+func (vs *valueSet) add(v string) {
+	if v = strings.TrimSpace(v); v == "" {
+		return
+	}
+
+	if vs.seen == nil {
+		vs.seen = make(map[string]bool)
+	}
+
+	key := strings.ToLower(v)
+	if vs.seen[key] {
+		return
+	}
+
+	vs.seen[key] = true
+	vs.values = append(vs.values, v)
+}
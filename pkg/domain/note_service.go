@@ -6,37 +6,117 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"html"
 	"log"
+	"path"
 	"regexp"
 	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/microcosm-cc/bluemonday"
 )
 
 const (
-	titlePrefix      = "Back"
-	createTimePrefix = "Create Time: "
-	modTimePrefix    = "Modify Time: "
-	tsFormat         = "02/01/2006 15:04"
-	headerLines      = 5
+	headerLines = 5
+	maxWrites   = 50 // maximum number of concurrent write operations
 )
 
+// ErrUnknownHeader is returned when the header of a raw note is not written with the labels of any header profile
+var ErrUnknownHeader = errors.New("cannot locate created or modified timestamp")
+
+// ErrNoRawHTML is returned when a Note cannot be re-cleaned because it does not retain its raw html
+var ErrNoRawHTML = errors.New("note does not retain its raw html")
+
 // NoteService provides note-related functionality
 type NoteService struct {
-	fs FileSystem
+	fs        FileSystem
+	ts        *TimestampParser
+	mode      ContentMode
+	workers   int
+	encoding  string         // encoding of raw files, detected per file if empty
+	labels    []headerLabels // labels of raw note headers, in order of preference
+	rules     *RuleSet       // user-defined rules applied to each note's title and content, if any
+	region    string         // region that phone numbers without an international prefix are parsed in
+	stopwords StopwordLists  // words of each language that are excluded from keywords
+	policy    FilenamePolicy // policy that each note's title is converted to a slug by
 }
 
-// ParseFromRawFile parses a Note from raw source at the provided file path
-func (ns *NoteService) ParseFromRawFile(path, id string) (Note, error) {
-	b, err := ns.fs.ReadFile(path)
-	if err != nil {
-		return Note{}, err
+// NoteServiceOption defines a function that configures a NoteService
+type NoteServiceOption func(ns *NoteService)
+
+// WithContentMode configures a NoteService to convert raw note content using the provided ContentMode
+func WithContentMode(m ContentMode) NoteServiceOption {
+	return func(ns *NoteService) {
+		ns.mode = m
 	}
+}
 
-	content := string(b)
-	sanitised, err := sanitiseInput(content)
+// WithWorkers configures a NoteService to parse notes using the provided number of concurrent workers
+func WithWorkers(workers int) NoteServiceOption {
+	return func(ns *NoteService) {
+		ns.workers = workers
+	}
+}
+
+// WithTimestampParser configures a NoteService to parse raw timestamps using the provided TimestampParser
+func WithTimestampParser(tp *TimestampParser) NoteServiceOption {
+	return func(ns *NoteService) {
+		ns.ts = tp
+	}
+}
+
+// WithEncoding configures a NoteService to decode raw files using the provided encoding rather than detecting it
+func WithEncoding(encoding string) NoteServiceOption {
+	return func(ns *NoteService) {
+		ns.encoding = encoding
+	}
+}
+
+// WithHeaderProfiles configures a NoteService to parse raw note headers using the provided HeaderProfiles,
+// in preference to the default profiles
+func WithHeaderProfiles(profiles ...HeaderProfile) NoteServiceOption {
+	return func(ns *NoteService) {
+		for _, p := range profiles {
+			ns.labels = append(ns.labels, newHeaderLabels(p))
+		}
+	}
+}
+
+// WithRuleSet configures a NoteService to apply the provided user-defined RuleSet to the title and content of each note
+func WithRuleSet(rs *RuleSet) NoteServiceOption {
+	return func(ns *NoteService) {
+		ns.rules = rs
+	}
+}
+
+// WithPhoneRegion configures a NoteService to parse phone numbers without an international prefix in the provided region
+func WithPhoneRegion(region string) NoteServiceOption {
+	return func(ns *NoteService) {
+		ns.region = region
+	}
+}
+
+// WithStopwords configures a NoteService to exclude the provided words of each language from keywords,
+// replacing the default stopwords of those languages
+func WithStopwords(words map[string][]string) NoteServiceOption {
+	return func(ns *NoteService) {
+		ns.stopwords = NewStopwordLists(words)
+	}
+}
+
+// WithFilenamePolicy configures a NoteService to convert the title of each note to a slug using the provided FilenamePolicy
+func WithFilenamePolicy(p FilenamePolicy) NoteServiceOption {
+	return func(ns *NoteService) {
+		ns.policy = p
+	}
+}
+
+// ParseFromRawFile parses a Note with the provided source folder and id from raw source at the provided file path
+func (ns *NoteService) ParseFromRawFile(path, folder, id string) (Note, error) {
+	raw, encoding, err := ns.readRawFile(path)
 	if err != nil {
 		return Note{}, err
 	}
@@ -44,21 +124,216 @@ func (ns *NoteService) ParseFromRawFile(path, id string) (Note, error) {
 	n := Note{
 		ID:           id,
 		OriginalPath: path,
+		SourceFolder: folder,
+		Encoding:     encoding,
+		ContentMode:  ns.mode,
+		RawHTML:      raw,
 	}
 
-	if err := parseTitle(sanitised, &n.Title); err != nil {
+	h, err := ns.parseRawHTML(&n, AttachmentDir(folder, id))
+	if err != nil {
 		return Note{}, err
 	}
-	if err := parseTimestamp(sanitised, &n.Timestamp); err != nil {
+
+	if n.Warnings, err = parseTimestamps(h, ns.ts, &n.CreatedAt, &n.ModifiedAt); err != nil {
 		return Note{}, err
 	}
-	if err := parseNoteContent(sanitised, &n.Content); err != nil {
+
+	return n, nil
+}
+
+// ParseTimestamp parses the provided raw timestamp using the configured timestamp parser, along with the provided
+// layouts, so that timestamps read from sources other than raw files respect the configured location and layouts
+func (ns *NoteService) ParseTimestamp(raw string, layouts ...string) (time.Time, error) {
+	return ns.ts.ParseWithLayouts(raw, layouts...)
+}
+
+// RecleanNote re-parses the provided Note from its raw html using the current parser, extracting its metadata again
+//
+// The Note's id, slug and timestamps are retained so that its filename is unchanged,
+// along with its attachments, which have already been written, and its warnings, which concern its timestamps.
+func (ns *NoteService) RecleanNote(n Note) (Note, error) {
+	if n.RawHTML == "" {
+		return Note{}, ErrNoRawHTML
+	}
+
+	parsed := Note{
+		ID:          n.ID,
+		ContentMode: n.ContentMode,
+		RawHTML:     n.RawHTML,
+	}
+
+	if parsed.ContentMode == "" {
+		parsed.ContentMode = ns.mode
+	}
+
+	if _, err := ns.parseRawHTML(&parsed, inlineAttachmentDir(n)); err != nil {
 		return Note{}, err
 	}
 
+	n.Title = parsed.Title
+	n.TitleInferred = parsed.TitleInferred
+	n.Content = parsed.Content
+	n.ContentMode = parsed.ContentMode
+	n.Checklist = parsed.Checklist
+	n.Links = parsed.Links
+	n.Emails = parsed.Emails
+	n.Phones = parsed.Phones
+	n.Hashtags = parsed.Hashtags
+
 	return n, nil
 }
 
+// inlineAttachmentDir returns the directory that the inline attachments of the provided cleaned Note were written to,
+// so that notes cleaned before attachment directories were qualified by folder keep referencing them
+func inlineAttachmentDir(n Note) string {
+	for _, a := range n.Attachments {
+		if strings.HasPrefix(path.Base(a.Path), inlineAttachmentPrefix) {
+			return path.Dir(a.Path)
+		}
+	}
+
+	return AttachmentDir(n.SourceFolder, n.ID)
+}
+
+// parseRawHTML parses the title, content, inline attachments and metadata of the provided Note from its raw html,
+// using its content mode and extracting inline attachments to the provided directory, returning its raw header
+func (ns *NoteService) parseRawHTML(n *Note, attachmentDir string) (rawHeader, error) {
+	content, attachments, err := extractInlineAttachments(markCheckboxes(n.RawHTML), attachmentDir)
+	if err != nil {
+		return rawHeader{}, err
+	}
+
+	sanitised, err := sanitiseInput(content)
+	if err != nil {
+		return rawHeader{}, err
+	}
+
+	h, err := parseHeader(sanitised, ns.labels)
+	if err != nil {
+		return rawHeader{}, err
+	}
+
+	n.Title = h.title
+	n.Attachments = attachments
+
+	switch n.ContentMode {
+	case MarkdownContent:
+		if err := parseMarkdownContent(content, h.labels, &n.Content); err != nil {
+			return rawHeader{}, err
+		}
+	default:
+		n.Content = parseNoteContent(sanitised, h)
+	}
+
+	n.Checklist, n.Content = parseChecklist(n.Content, n.ContentMode)
+
+	if n.Title == "" {
+		n.Title = inferTitle(parseNoteContent(sanitised, h))
+		n.TitleInferred = n.Title != ""
+	}
+
+	ns.applyRules(n)
+	extractMetadata(n, content, ns.region)
+
+	return h, nil
+}
+
+// RawFormat represents the format that raw files are written in
+type RawFormat struct {
+	Locale string // locale of the header profile whose labels the raw files are written with
+	Layout string // layout of the raw timestamps
+}
+
+// DetectRawFormat detects the header profile that matches the most raw files at the provided paths,
+// and the timestamp layout that consistently parses every raw file
+//
+// The detected format is preferred for all raw files parsed subsequently.
+func (ns *NoteService) DetectRawFormat(paths []string) (RawFormat, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	results := runOrdered(ctx, len(paths), ns.workers, func(idx int) interface{} {
+		return ns.parseRawHeader(paths[idx])
+	})
+
+	var raws []string
+	matches := make(map[string]int)
+
+	for r := range results {
+		h, ok := r.(rawHeader)
+		if !ok {
+			continue
+		}
+
+		matches[h.labels.profile.Locale]++
+
+		for _, raw := range []string{h.created, h.modified} {
+			if raw != "" {
+				raws = append(raws, raw)
+			}
+		}
+	}
+
+	if len(matches) == 0 {
+		return RawFormat{}, errors.New("cannot detect header labels: no raw file matches any header profile")
+	}
+
+	// prefer the profile that matches the most raw files, retaining the order of preference otherwise
+	best := 0
+	for idx, l := range ns.labels {
+		if matches[l.profile.Locale] > matches[ns.labels[best].profile.Locale] {
+			best = idx
+		}
+	}
+
+	preferred := []headerLabels{ns.labels[best]}
+	for idx, l := range ns.labels {
+		if idx != best {
+			preferred = append(preferred, l)
+		}
+	}
+	ns.labels = preferred
+
+	if err := ns.ts.Detect(raws); err != nil {
+		return RawFormat{}, fmt.Errorf("cannot detect timestamp layout: %w", err)
+	}
+
+	return RawFormat{Locale: ns.labels[0].profile.Locale, Layout: ns.ts.Layout()}, nil
+}
+
+// parseRawHeader returns the header of the raw file at the provided path
+//
+// Files that cannot be read or parsed return nil, as they will fail to parse subsequently.
+func (ns *NoteService) parseRawHeader(path string) interface{} {
+	raw, _, err := ns.readRawFile(path)
+	if err != nil {
+		return nil
+	}
+
+	sanitised, err := sanitiseInput(raw)
+	if err != nil {
+		return nil
+	}
+
+	h, err := parseHeader(sanitised, ns.labels)
+	if err != nil {
+		return nil
+	}
+
+	return h
+}
+
+// readRawFile reads the raw file at the provided path as utf-8, returning the name of its source encoding
+func (ns *NoteService) readRawFile(path string) (string, string, error) {
+	b, err := ns.fs.ReadFile(path)
+	if err != nil {
+		return "", "", err
+	}
+
+	return decodeRaw(b, ns.encoding)
+}
+
 // ParseFromFile parses a Note from the provided source file path
 func (ns *NoteService) ParseFromFile(path string) (Note, error) {
 	payload, err := ns.fs.ReadFile(path)
@@ -78,17 +353,19 @@ func (ns *NoteService) ParseFromFile(path string) (Note, error) {
 	return n, nil
 }
 
-// ParseFromFiles parses Notes from the files at the provided paths
+// ParseFromFiles parses Notes from the files at the provided paths, concurrently but retaining their order
 func (ns *NoteService) ParseFromFiles(paths []string) ([]Note, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
 	var notes []Note
 
-	for _, p := range paths {
-		n, err := ns.ParseFromFile(p)
-		if err != nil {
-			return nil, fmt.Errorf("cannot parse note from file %s: %w", p, err)
+	for r := range StreamNotes(ctx, paths, ns.workers, ns.ParseFromFile) {
+		if r.Err != nil {
+			return nil, fmt.Errorf("cannot parse note from file %s: %w", r.Source, r.Err)
 		}
 
-		notes = append(notes, n)
+		notes = append(notes, r.Note)
 	}
 
 	return notes, nil
@@ -122,57 +399,101 @@ func (ns *NoteService) ParseManifestFromPath(path string) (NoteManifest, error)
 
 // WriteNotes writes the provided notes using the provided NoteWriter
 func (ns *NoteService) WriteNotes(ctx context.Context, notes []Note, nw NoteWriter) (int, error) {
+	noteCh := make(chan Note)
+
 	ctxWithCancel, cancel := context.WithCancel(ctx)
 	defer cancel()
 
-	errCh := make(chan error, 1)
-	noteCh := make(chan Note, len(notes))
-
 	go func() {
-		sem := make(chan struct{}, 50) // ensure no more than 50 concurrent operations
-		for idx, n := range notes {
-			// check whether operation has ended
+		defer close(noteCh)
+		for _, n := range notes {
 			select {
+			case noteCh <- n:
 			case <-ctxWithCancel.Done():
 				return
-			default:
 			}
+		}
+	}()
+
+	return ns.WriteNoteStream(ctxWithCancel, noteCh, nw)
+}
+
+// WriteNoteStream writes each of the notes received from the provided channel using the provided NoteWriter,
+// until the channel is closed
+//
+// Notes keep any filename already assigned to them, and any that still collide are suffixed in the order that they
+// are received.
+func (ns *NoteService) WriteNoteStream(ctx context.Context, notes <-chan Note, nw NoteWriter) (int, error) {
+	ctxWithCancel, cancel := context.WithCancel(ctx)
+	defer cancel()
 
-			n.Index = idx
+	errCh := make(chan error, 1)
+	sem := make(chan struct{}, maxWrites) // ensure no more than maxWrites concurrent operations
+	wg := &sync.WaitGroup{}
 
-			// otherwise continue for current note
-			sem <- struct{}{}
-			go func(n Note) {
-				defer func() {
-					<-sem
-				}()
+	var count int64
+	var filenames FilenameSet
 
-				if err := nw.Write(n); err != nil {
-					errCh <- fmt.Errorf("error writing note %d: %w", n.Index, err)
-					return
-				}
+	write := func(n Note) {
+		defer func() {
+			<-sem
+			wg.Done()
+		}()
 
-				noteCh <- n
-			}(n)
+		if err := nw.Write(n); err != nil {
+			select {
+			case errCh <- fmt.Errorf("error writing note with id %s: %w", n.ID, err):
+				cancel()
+			default:
+			}
+			return
 		}
-	}()
 
-	total := len(notes)
-	count := 0
+		log.Printf("written note %d", atomic.AddInt64(&count, 1))
+	}
 
+loop:
 	for {
 		select {
-		case err := <-errCh:
-			cancel()
-			return 0, fmt.Errorf("failed to create note: %w", err)
-		case <-noteCh:
-			count++
-			log.Printf("written note %d/%d", count, total)
-			if count == total {
-				return count, nil
+		case <-ctxWithCancel.Done():
+			break loop
+		case n, ok := <-notes:
+			if !ok {
+				break loop
+			}
+
+			if err := filenames.Assign(&n); err != nil {
+				select {
+				case errCh <- err:
+				default:
+				}
+				break loop
 			}
+
+			select {
+			case sem <- struct{}{}:
+			case <-ctxWithCancel.Done():
+				break loop
+			}
+
+			wg.Add(1)
+			go write(n)
 		}
 	}
+
+	wg.Wait()
+
+	select {
+	case err := <-errCh:
+		return 0, fmt.Errorf("failed to create note: %w", err)
+	default:
+	}
+
+	if err := ctx.Err(); err != nil {
+		return int(count), err
+	}
+
+	return int(count), nil
 }
 
 // FilterNotesByManifest returns the provided Notes based on the provided manifest
@@ -228,117 +549,284 @@ func (ns *NoteService) SaveManifest(m NoteManifest) error {
 	return nil
 }
 
-// NewNoteService returns a new NoteService using the provided FileSystem
-func NewNoteService(fs FileSystem) *NoteService {
-	return &NoteService{fs: fs}
+// NewNoteService returns a new NoteService using the provided FileSystem and options
+func NewNoteService(fs FileSystem, opts ...NoteServiceOption) *NoteService {
+	ns := &NoteService{fs: fs}
+
+	for _, opt := range opts {
+		opt(ns)
+	}
+
+	if ns.ts == nil {
+		ns.ts = defaultTimestampParser()
+	}
+
+	if ns.mode == "" {
+		ns.mode = PlainTextContent
+	}
+
+	if ns.workers < 1 {
+		ns.workers = DefaultWorkers
+	}
+
+	if ns.region == "" {
+		ns.region = DefaultPhoneRegion
+	}
+
+	if ns.stopwords == nil {
+		ns.stopwords = make(StopwordLists)
+	}
+
+	// default stopwords are used for each language that is not provided
+	for lang, list := range NewStopwordLists(DefaultStopwords) {
+		if _, ok := ns.stopwords[lang]; !ok {
+			ns.stopwords[lang] = list
+		}
+	}
+
+	// default profiles are always considered, after any that are provided
+	for _, p := range DefaultHeaderProfiles {
+		ns.labels = append(ns.labels, newHeaderLabels(p))
+	}
+
+	return ns
 }
 
-// sanitiseInput sanitises the provided input string
-func sanitiseInput(inp string) (string, error) {
-	var findReplace = func(inp string, fr map[string]string) string {
-		filtered := inp
-		for f, r := range fr {
-			rgx, err := regexp.Compile(f)
-			if err != nil {
-				log.Fatal(err)
-			}
-			filtered = string(rgx.ReplaceAll([]byte(filtered), []byte(r)))
-			filtered = strings.Trim(filtered, " \n")
+// applyRules applies the user-defined rules to the title and content of the provided Note, then sets its slug
+//
+// Content rules also apply to the text of each checklist item. The matches within an item that is rendered in place
+// are only counted once, within the content.
+func (ns *NoteService) applyRules(n *Note) {
+	if ns.rules.Len() > 0 {
+		checklist := make([]ChecklistItem, len(n.Checklist))
+
+		for idx, item := range n.Checklist {
+			inPlace := strings.Contains(n.Content, item.String())
+			item.Text = strings.TrimSpace(ns.rules.apply(ContentScope, item.Text, !inPlace))
+			checklist[idx] = item
 		}
-		return filtered
+
+		n.Title = strings.TrimSpace(ns.rules.apply(TitleScope, n.Title, true))
+		n.Content = ns.rules.apply(ContentScope, n.Content, true)
+		n.Checklist = checklist
 	}
 
-	filtered := inp
+	n.Slug = ns.policy.Slugify(n.Title)
+}
 
-	// initial character replacements
-	filtered = findReplace(filtered, map[string]string{
-		"&ensp;":   " ",
-		"&quot;":   "\"",
-		"&amp;":    "&",
-		"<br>":     "\n",
-		"<p(.*?)>": "\n\n",
-	})
+// findReplaceRule defines a pattern to find and the value to replace each of its matches with
+type findReplaceRule struct {
+	find    *regexp.Regexp
+	replace string
+}
 
-	// strip tags
-	filtered = bluemonday.StripTagsPolicy().Sanitize(filtered)
+// structuralRules converts line-breaking html into line breaks before tags are stripped, applied in order
+var structuralRules = []findReplaceRule{
+	{find: regexp.MustCompile(`<br>`), replace: "\n"},
+	{find: regexp.MustCompile(`<p(.*?)>`), replace: "\n\n"},
+}
 
-	// add non-sanitised characters back in
-	filtered = findReplace(filtered, map[string]string{
-		"&#39;": "'",
-		"&#34;": "\"",
-	})
+// whitespaceRules normalises decoded whitespace characters, applied in order
+var whitespaceRules = []findReplaceRule{
+	{find: regexp.MustCompile(`[^\P{Zs} ]`), replace: " "}, // space separators, such as non-breaking and en spaces
+}
+
+// stripTagsPolicy strips all html tags, escaping the resulting text
+var stripTagsPolicy = bluemonday.StripTagsPolicy()
+
+// sanitiseInput sanitises the provided input string
+//
+// Each stage is applied in a fixed order so that output is identical for identical input,
+// and all named and numeric html entities are decoded exactly once.
+func sanitiseInput(inp string) (string, error) {
+	filtered := findReplace(inp, structuralRules)
+
+	// strip tags, which decodes entities within the source and escapes the resulting text
+	filtered = stripTagsPolicy.Sanitize(filtered)
+
+	// decode the escaped text
+	filtered = html.UnescapeString(filtered)
+
+	filtered = findReplace(filtered, whitespaceRules)
+	filtered = strings.Trim(filtered, " \n")
 
 	return fmt.Sprintf("%s\n", filtered), nil
 }
 
-// parseTitle parses title from a string of sanitised file contents
-func parseTitle(inp string, t *string) error {
+// findReplace applies each of the provided rules to the provided input in turn
+func findReplace(inp string, rules []findReplaceRule) string {
+	filtered := inp
+
+	for _, r := range rules {
+		filtered = r.find.ReplaceAllString(filtered, r.replace)
+	}
+
+	return filtered
+}
+
+// rawHeader represents the header of a raw note
+type rawHeader struct {
+	title    string       // title of the note
+	created  string       // raw created timestamp, empty if not located
+	modified string       // raw modified timestamp, empty if not located
+	body     int          // index of the line that note content begins from
+	labels   headerLabels // labels that the header is written with
+}
+
+// parseHeader parses the header from a string of sanitised file contents,
+// using the first of the provided labels that locates a timestamp
+func parseHeader(inp string, labels []headerLabels) (rawHeader, error) {
 	lines, err := parseLines(inp)
 	if err != nil {
-		return err
+		return rawHeader{}, err
+	}
+
+	for _, l := range labels {
+		if h, ok := parseHeaderWithLabels(lines, l); ok {
+			return h, nil
+		}
 	}
 
-	tLine := lines[0]
-	parts := strings.Split(tLine, titlePrefix)
+	return rawHeader{}, ErrUnknownHeader
+}
 
-	title := parts[0]
-	if len(parts) == 2 {
-		title = parts[1]
+// parseHeaderWithLabels parses the header from the provided lines of sanitised file contents using the provided labels,
+// returning false if neither timestamp can be located
+func parseHeaderWithLabels(lines []string, l headerLabels) (rawHeader, bool) {
+	h := rawHeader{
+		title:  parseTitle(lines[0], l),
+		labels: l,
 	}
 
-	title = strings.ToLower(title)
-	*t = strings.Trim(title, " \n")
+	// timestamps are expected on the lines following the title, but tolerate either being absent
+	last := 0
+	for idx := 1; idx < headerLines-1; idx++ {
+		switch line := lines[idx]; {
+		case l.created.MatchString(line):
+			h.created = strings.Trim(l.created.ReplaceAllString(line, ""), " \n")
+			last = idx
+		case l.modified.MatchString(line):
+			h.modified = strings.Trim(l.modified.ReplaceAllString(line, ""), " \n")
+			last = idx
+		}
+	}
 
-	return nil
+	if last == 0 {
+		return rawHeader{}, false
+	}
+
+	h.body = last + 1
+
+	return h, true
 }
 
-// parseTimestamp parses modified date from a string of sanitised file contents
-func parseTimestamp(inp string, t *time.Time) error {
-	lines, err := parseLines(inp)
-	if err != nil {
-		return err
-	}
+// parseTitle parses title from the title line of sanitised file contents, following the back label if present
+func parseTitle(tLine string, l headerLabels) string {
+	return strings.Trim(l.back.ReplaceAllString(tLine, ""), " \n")
+}
 
-	cLine := lines[2]
-	mLine := lines[3]
+// inferTitle infers a title from the first non-empty line of the provided plain text note content
+func inferTitle(inp string) string {
+	// fall back to checklist items if there is no other content
+	var lines []string
+	for _, line := range strings.Split(inp, "\n") {
+		if !checklistItemRgx.MatchString(line) {
+			lines = append(lines, line)
+		}
+	}
 
-	if !strings.HasPrefix(cLine, createTimePrefix) {
-		return errors.New("cannot locate created timestamp")
+	items, _ := parseChecklist(inp, PlainTextContent)
+	for _, item := range items {
+		lines = append(lines, item.Text)
 	}
-	if !strings.HasPrefix(mLine, modTimePrefix) {
-		return errors.New("cannot locate modified timestamp")
+
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		runes := []rune(line)
+		if len(runes) > maxInferredTitleLen {
+			line = strings.TrimSpace(string(runes[:maxInferredTitleLen]))
+		}
+
+		return line
 	}
 
-	parts := strings.Split(mLine, modTimePrefix)
-	if len(parts) != 2 {
-		return errors.New("cannot locate timestamp within modified line")
+	return ""
+}
+
+// parseTimestamps parses created and modified dates from the provided header
+//
+// Where only one of the timestamps can be parsed, it is used for both and a warning is returned.
+func parseTimestamps(h rawHeader, tp *TimestampParser, created, modified *time.Time) ([]string, error) {
+	var cErr, mErr error
+
+	if h.created == "" {
+		cErr = errors.New("cannot locate created timestamp")
+	} else if *created, cErr = tp.Parse(h.created); cErr != nil {
+		cErr = fmt.Errorf("cannot parse created timestamp: %w", cErr)
 	}
 
-	ts, err := time.Parse(tsFormat, strings.Trim(parts[1], " \n"))
-	if err != nil {
-		return err
+	if h.modified == "" {
+		mErr = errors.New("cannot locate modified timestamp")
+	} else if *modified, mErr = tp.Parse(h.modified); mErr != nil {
+		mErr = fmt.Errorf("cannot parse modified timestamp: %w", mErr)
 	}
 
-	loc, err := time.LoadLocation("Europe/London")
-	if err != nil {
-		return err
+	switch {
+	case cErr != nil && mErr != nil:
+		return nil, fmt.Errorf("%s: %w", cErr.Error(), mErr)
+	case cErr != nil:
+		*created = *modified
+		return []string{fmt.Sprintf("%s, using modified timestamp", cErr)}, nil
+	case mErr != nil:
+		*modified = *created
+		return []string{fmt.Sprintf("%s, using created timestamp", mErr)}, nil
 	}
 
-	*t = time.Date(ts.Year(), ts.Month(), ts.Day(), ts.Hour(), ts.Minute(), 0, 0, loc)
+	return nil, nil
+}
 
-	return nil
+// parseNoteContent parses note content that follows the provided header from a string of sanitised file contents
+func parseNoteContent(inp string, h rawHeader) string {
+	lines := strings.Split(inp, "\n")
+
+	contentLines := lines[h.body:]
+	content := strings.Join(contentLines, "\n")
+
+	return strings.TrimLeft(content, " \n")
 }
 
-// parseNoteContent parses note content from a string of sanitised file contents
-func parseNoteContent(inp string, c *string) error {
-	lines, err := parseLines(inp)
+// parseMarkdownContent parses note content as markdown from a string of raw file contents,
+// whose header is written with the provided labels
+func parseMarkdownContent(inp string, labels headerLabels, c *string) error {
+	md, err := htmlToMarkdown(inp)
 	if err != nil {
 		return err
 	}
 
-	contentLines := lines[headerLines-1:]
-	content := strings.Join(contentLines, "\n")
-	*c = strings.TrimLeft(content, " \n")
+	// content follows the last timestamp line of the header
+	lines := strings.Split(md, "\n")
+	end := -1
+	for idx, l := range lines {
+		l = strings.TrimSpace(l)
+		if labels.isTimestampLine(l) {
+			end = idx
+			continue
+		}
+		if end >= 0 && l != "" {
+			break
+		}
+	}
+
+	if end < 0 {
+		return errors.New("cannot locate end of header in markdown")
+	}
+
+	content := strings.Join(lines[end+1:], "\n")
+	*c = fmt.Sprintf("%s\n", strings.Trim(content, " \n"))
 
 	return nil
 }
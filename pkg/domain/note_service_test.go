@@ -0,0 +1,95 @@
+package domain
+
+import "testing"
+
+func TestSanitiseInput(t *testing.T) {
+	tt := []struct {
+		name string
+		inp  string
+		want string
+	}{
+		{
+			name: "named entities of accented letters are decoded",
+			inp:  "Caf&eacute; cr&egrave;me br&ucirc;l&eacute;e",
+			want: "Café crème brûlée\n",
+		},
+		{
+			name: "accented letters are retained",
+			inp:  "Crème brûlée à la française",
+			want: "Crème brûlée à la française\n",
+		},
+		{
+			name: "cjk text is retained and numeric entities are decoded",
+			inp:  "会议&#35760;录 日本語の<b>メモ</b>",
+			want: "会议记录 日本語のメモ\n",
+		},
+		{
+			name: "emoji are retained and decimal and hex entities are decoded",
+			inp:  "&#x1F600; &#128512; 👍🏽",
+			want: "😀 😀 👍🏽\n",
+		},
+		{
+			name: "double-encoded entities are decoded once",
+			inp:  "&amp;quot;quoted&amp;quot; &amp;amp;",
+			want: "&quot;quoted&quot; &amp;\n",
+		},
+		{
+			name: "escaped tags are decoded rather than stripped",
+			inp:  "&lt;b&gt;bold&lt;/b&gt;",
+			want: "<b>bold</b>\n",
+		},
+		{
+			name: "special spaces are normalised",
+			inp:  "a&nbsp;b\u2002c\u2003d&thinsp;e\u202ff\u3000g",
+			want: "a b c d e f g\n",
+		},
+		{
+			name: "tabs are retained",
+			inp:  "a\tb",
+			want: "a\tb\n",
+		},
+		{
+			name: "line-breaking tags are converted to line breaks",
+			inp:  "one<br>two<p>three</p>",
+			want: "one\ntwo\n\nthree\n",
+		},
+		{
+			name: "surrounding whitespace is trimmed",
+			inp:  "  \n Tom &amp; Jerry \n ",
+			want: "Tom & Jerry\n",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := sanitiseInput(tc.inp)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSanitiseInput_Deterministic(t *testing.T) {
+	inp := "&amp;quot;Caf&eacute;&amp;quot; &lt;会议&gt; &#x1F600;&nbsp;&amp;amp;&amp;lt;p&amp;gt;<p>done</p>"
+
+	want, err := sanitiseInput(inp)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for i := 0; i < 100; i++ {
+		got, err := sanitiseInput(inp)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if got != want {
+			t.Fatalf("run %d: got %q, want %q", i, got, want)
+		}
+	}
+}
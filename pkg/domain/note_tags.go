@@ -0,0 +1,77 @@
+package domain
+
+import (
+	"sort"
+	"strings"
+)
+
+// TagPrefix defines the prefix that distinguishes a tag from a category when entered alongside one
+const TagPrefix = "#"
+
+// tagIndexDir defines the name of the directory that tag indexes are written to
+const tagIndexDir = "_tags"
+
+// reservedNames defines the names within an output directory that are written by indexes or archived notes,
+// which notes cannot be written within
+var reservedNames = map[string]bool{
+	tagIndexDir:                true,
+	archiveDir:                 true,
+	flagIndexName(StarredFlag): true,
+	flagIndexName(PinnedFlag):  true,
+}
+
+// tagPolicy defines the policy that tags are normalised by, so that each tag can be used as a filename
+var tagPolicy = FilenamePolicy{Mode: UnicodeSlugs, Portable: true}
+
+// ParseTags returns the normalised tags of the provided values, with any tag prefix removed,
+// de-duplicated and in order of first appearance
+func ParseTags(values ...string) []string {
+	var tags []string
+	seen := make(map[string]bool)
+
+	for _, v := range values {
+		tag := tagPolicy.Slugify(strings.TrimLeft(strings.TrimSpace(v), TagPrefix))
+		if tag == "" || seen[tag] {
+			continue
+		}
+
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+
+	return tags
+}
+
+// IsReservedPath returns true if the provided path, relative to an output directory, is within a name that is
+// written by indexes or archived notes, regardless of case
+func IsReservedPath(p string) bool {
+	return reservedNames[filenameKey(strings.SplitN(p, "/", 2)[0])]
+}
+
+// BuildTagIndexes returns an index of the provided Notes for each of their tags, in order of tag,
+// listing starred and pinned notes first and then in order of path
+func (ns *NoteService) BuildTagIndexes(notes []Note) []NoteIndex {
+	byTag := make(map[string][]Note)
+
+	for _, n := range notes {
+		for _, tag := range n.Tags {
+			byTag[tag] = append(byTag[tag], n)
+		}
+	}
+
+	var tags []string
+	for tag := range byTag {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	var indexes []NoteIndex
+	for _, tag := range tags {
+		tagged := byTag[tag]
+		sortIndexNotes(tagged)
+
+		indexes = append(indexes, NoteIndex{Name: tagIndexDir + "/" + tag, Notes: tagged})
+	}
+
+	return indexes
+}
@@ -0,0 +1,82 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNote_Filename(t *testing.T) {
+	created := time.Date(2021, 3, 5, 10, 0, 0, 0, time.UTC)
+	modified := time.Date(2021, 4, 6, 11, 30, 0, 0, time.UTC)
+
+	tt := []struct {
+		name   string
+		dateBy TimestampKind
+		want   string
+	}{
+		{
+			name: "notes are dated by their created timestamp by default",
+			want: "2021-03-05_shopping",
+		},
+		{
+			name:   "notes are dated by their created timestamp",
+			dateBy: CreatedTimestamp,
+			want:   "2021-03-05_shopping",
+		},
+		{
+			name:   "notes are dated by their modified timestamp",
+			dateBy: ModifiedTimestamp,
+			want:   "2021-04-06_shopping",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			n := Note{Slug: "shopping", CreatedAt: created, ModifiedAt: modified, DateBy: tc.dateBy}
+
+			if got := n.Filename(); got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSortNotesByFilenameDesc(t *testing.T) {
+	// the earliest created note is the latest modified
+	notes := func(dateBy TimestampKind) []Note {
+		return []Note{
+			{ID: "101", Slug: "shopping", CreatedAt: time.Date(2021, 3, 5, 0, 0, 0, 0, time.UTC), ModifiedAt: time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC), DateBy: dateBy},
+			{ID: "102", Slug: "shopping", CreatedAt: time.Date(2021, 4, 5, 0, 0, 0, 0, time.UTC), ModifiedAt: time.Date(2021, 5, 1, 0, 0, 0, 0, time.UTC), DateBy: dateBy},
+		}
+	}
+
+	tt := []struct {
+		name   string
+		dateBy TimestampKind
+		want   []string
+	}{
+		{
+			name: "notes are sorted by their created timestamp by default",
+			want: []string{"102", "101"},
+		},
+		{
+			name:   "notes are sorted by their modified timestamp",
+			dateBy: ModifiedTimestamp,
+			want:   []string{"101", "102"},
+		},
+	}
+
+	ns := NewNoteService(&memFileSystem{})
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			sorted := ns.SortNotesByFilenameDesc(notes(tc.dateBy))
+
+			for idx, n := range sorted {
+				if n.ID != tc.want[idx] {
+					t.Errorf("got note %d id %q, want %q", idx, n.ID, tc.want[idx])
+				}
+			}
+		})
+	}
+}
@@ -4,3 +4,15 @@ package domain
 type NoteWriter interface {
 	Write(n Note) error
 }
+
+// NoteIndex represents a named list of notes, such as the notes with a particular tag
+type NoteIndex struct {
+	Name  string // name of the index, which may contain forward slashes
+	Notes []Note // notes listed by the index, in order
+}
+
+// IndexWriter defines the behaviour for writing a NoteIndex alongside the notes that it lists,
+// which a NoteWriter may optionally implement
+type IndexWriter interface {
+	WriteIndex(parentDir string, idx NoteIndex) error
+}
@@ -0,0 +1,224 @@
+package domain
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+)
+
+// DefaultPathTemplate defines the template of the path that a Note is written to by default,
+// within a directory per category
+const DefaultPathTemplate = "{{with .Category}}{{.}}/{{end}}{{.Filename}}"
+
+// maxPathSegmentLen defines the maximum length in bytes of a single segment of a rendered path
+const maxPathSegmentLen = 200
+
+// unsafePathChars defines the characters that cannot be written within a segment of a rendered path
+const unsafePathChars = `\:*?"<>|`
+
+// pathFieldReplacement defines the character that replaces any character of a field value that cannot be written
+// within a segment of a rendered path
+const pathFieldReplacement = '_'
+
+// PathData represents the fields of a Note that are available to a PathTemplate
+//
+// Text fields are escaped, so that a value can never nest a path within directories or make it unwritable, and every
+// forward slash of a rendered path comes from its template.
+type PathData struct {
+	ID           string    // id of the note
+	Title        string    // title of the note, as written
+	Slug         string    // filename-safe representation of the title
+	Filename     string    // unique filename of the note, as used by the manifest
+	Category     string    // category of the note, empty until categorised
+	SourceFolder string    // name of the export folder that the note was found in
+	Timestamp    time.Time // timestamp that the note is dated by
+	CreatedAt    time.Time // timestamp that the note was created
+	ModifiedAt   time.Time // timestamp that the note was last modified
+}
+
+// PathTemplate renders the path that a Note is written to, relative to its output directory and excluding its extension
+type PathTemplate struct {
+	pattern string
+	tmpl    *template.Template
+}
+
+// ParsePathTemplate parses a PathTemplate from the provided text/template pattern,
+// validating it against a sample note
+func ParsePathTemplate(pattern string) (*PathTemplate, error) {
+	tmpl, err := template.New("path").Option("missingkey=error").Parse(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse path template %s: %w", pattern, err)
+	}
+
+	pt := &PathTemplate{pattern: pattern, tmpl: tmpl}
+
+	sample := Note{
+		ID:           "1",
+		Title:        "Sample",
+		Slug:         "sample",
+		Category:     "category",
+		SourceFolder: "folder",
+		CreatedAt:    time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC),
+		ModifiedAt:   time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC),
+	}
+
+	if _, err := pt.Render(sample); err != nil {
+		return nil, fmt.Errorf("invalid path template %s: %w", pattern, err)
+	}
+
+	return pt, nil
+}
+
+// ParsePathTemplates parses a PathTemplate from each of the provided patterns, in order of preference
+func ParsePathTemplates(patterns []string) ([]*PathTemplate, error) {
+	var templates []*PathTemplate
+
+	for _, p := range patterns {
+		pt, err := ParsePathTemplate(p)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, pt)
+	}
+
+	return templates, nil
+}
+
+// String implements fmt.Stringer
+func (pt *PathTemplate) String() string {
+	return pt.pattern
+}
+
+// Render returns the path of the provided Note, using forward slashes to separate directories
+func (pt *PathTemplate) Render(n Note) (string, error) {
+	data := PathData{
+		ID:           escapePathField(n.ID),
+		Title:        escapePathField(n.Title),
+		Slug:         escapePathField(n.Slug),
+		Filename:     escapePathField(n.Filename()),
+		Category:     escapePathField(n.Category),
+		SourceFolder: escapePathField(n.SourceFolder),
+		Timestamp:    n.Timestamp(),
+		CreatedAt:    n.CreatedAt,
+		ModifiedAt:   n.ModifiedAt,
+	}
+
+	var buf bytes.Buffer
+	if err := pt.tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("cannot render path: %w", err)
+	}
+
+	p := strings.TrimSpace(buf.String())
+
+	if err := ValidatePath(p); err != nil {
+		return "", fmt.Errorf("unsafe path %q: %w", p, err)
+	}
+
+	return p, nil
+}
+
+// escapePathField returns the provided field value with forward slashes, unsafe characters and control characters
+// replaced, and any surrounding spaces and trailing dots removed, so that it can be written within a segment of a path
+func escapePathField(v string) string {
+	v = strings.Map(func(r rune) rune {
+		if r == '/' || strings.ContainsRune(unsafePathChars, r) || unicode.IsControl(r) {
+			return pathFieldReplacement
+		}
+		return r
+	}, v)
+
+	return portableName(strings.TrimSpace(v))
+}
+
+// ValidatePath returns an error if the provided path, relative to its output directory using forward slashes,
+// could be written outside of its output directory or contains a segment that cannot be written
+func ValidatePath(p string) error {
+	if p == "" {
+		return errors.New("path is empty")
+	}
+
+	if strings.HasPrefix(p, "/") {
+		return errors.New("path is absolute")
+	}
+
+	for _, seg := range strings.Split(p, "/") {
+		switch {
+		case seg == "":
+			return errors.New("path contains an empty segment")
+		case seg == "." || seg == "..":
+			return fmt.Errorf("path contains relative segment %s", seg)
+		case strings.TrimSpace(seg) != seg:
+			return fmt.Errorf("segment %q begins or ends with a space", seg)
+		case strings.HasSuffix(seg, "."):
+			return fmt.Errorf("segment %q ends with a dot", seg)
+		case isWindowsReserved(seg):
+			return fmt.Errorf("segment %q is a reserved name on windows", seg)
+		case len(seg) > maxPathSegmentLen:
+			return fmt.Errorf("segment %q is longer than %d bytes", seg, maxPathSegmentLen)
+		case strings.ContainsAny(seg, unsafePathChars):
+			return fmt.Errorf("segment %q contains one of %s", seg, unsafePathChars)
+		case strings.IndexFunc(seg, unicode.IsControl) >= 0:
+			return fmt.Errorf("segment %q contains a control character", seg)
+		}
+	}
+
+	return nil
+}
+
+// ApplyPathTemplates sets the output path of each of the provided Notes using the first of the provided templates
+// that renders a unique path for every note, returning the notes along with the template used
+//
+// If flat, templates that nest any note within a directory are skipped.
+func (ns *NoteService) ApplyPathTemplates(notes []Note, templates []*PathTemplate, flat bool) ([]Note, *PathTemplate, error) {
+	var reasons []string
+
+	for _, pt := range templates {
+		paths, err := renderUniquePaths(notes, pt, flat)
+		if err != nil {
+			reasons = append(reasons, fmt.Sprintf("%s: %s", pt, err))
+			continue
+		}
+
+		var applied []Note
+		for idx, n := range notes {
+			n.OutPath = paths[idx]
+			applied = append(applied, n)
+		}
+
+		return applied, pt, nil
+	}
+
+	return nil, nil, fmt.Errorf("no path template renders a unique path for every note (%s)", strings.Join(reasons, "; "))
+}
+
+// renderUniquePaths renders the path of each of the provided Notes using the provided template,
+// returning an error if any two paths clash regardless of case or unicode composition, or if flat and any path is nested
+func renderUniquePaths(notes []Note, pt *PathTemplate, flat bool) ([]string, error) {
+	var paths []string
+	rendered := make(map[string]string)
+
+	for _, n := range notes {
+		p, err := pt.Render(n)
+		if err != nil {
+			return nil, fmt.Errorf("note with id %s: %w", n.ID, err)
+		}
+
+		if flat && strings.Contains(p, "/") {
+			return nil, fmt.Errorf("note with id %s renders nested path %s", n.ID, p)
+		}
+
+		key := filenameKey(p)
+		if id, ok := rendered[key]; ok {
+			return nil, fmt.Errorf("notes with ids %s and %s both render path %s", id, n.ID, p)
+		}
+
+		rendered[key] = n.ID
+		paths = append(paths, p)
+	}
+
+	return paths, nil
+}
@@ -0,0 +1,101 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPathTemplateRender(t *testing.T) {
+	tt := []struct {
+		name    string
+		pattern string
+		note    Note
+		want    string
+	}{
+		{
+			name:    "forward slashes come from the template",
+			pattern: `{{.Category}}/{{.Timestamp.Format "2006/01"}}/{{.Slug}}`,
+			note:    Note{Category: "work", Slug: "budget"},
+			want:    "work/2021/03/budget",
+		},
+		{
+			name:    "forward slashes within field values are escaped",
+			pattern: "{{.Category}}/{{.Title}}",
+			note:    Note{Category: "A/B testing", Title: "Results 1/2"},
+			want:    "A_B testing/Results 1_2",
+		},
+		{
+			name:    "unsafe characters within field values are escaped",
+			pattern: "{{.SourceFolder}}/{{.Title}}",
+			note:    Note{SourceFolder: "Work: Q1", Title: "What? <draft>"},
+			want:    "Work_ Q1/What_ _draft_",
+		},
+		{
+			name:    "field values are trimmed of spaces and trailing dots",
+			pattern: "{{.Title}}/{{.ID}}",
+			note:    Note{Title: " Misc... ", ID: "101"},
+			want:    "Misc/101",
+		},
+		{
+			name:    "relative field values cannot escape the output directory",
+			pattern: "{{.Category}}/{{.ID}}",
+			note:    Note{Category: "../..", ID: "101"},
+			want:    ".._/101",
+		},
+		{
+			name:    "default template nests the filename within an escaped category",
+			pattern: DefaultPathTemplate,
+			note:    Note{Category: "../x", Slug: "budget"},
+			want:    ".._x/2021-03-05_budget",
+		},
+		{
+			name:    "default template omits an empty category",
+			pattern: DefaultPathTemplate,
+			note:    Note{Slug: "budget"},
+			want:    "2021-03-05_budget",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			pt, err := ParsePathTemplate(tc.pattern)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			tc.note.CreatedAt = time.Date(2021, 3, 5, 10, 0, 0, 0, time.UTC)
+
+			got, err := pt.Render(tc.note)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestValidatePath(t *testing.T) {
+	tt := []struct {
+		name    string
+		path    string
+		wantErr bool
+	}{
+		{name: "nested path is valid", path: "work/2021-03-05_budget"},
+		{name: "parent segment is invalid", path: "../x/2021-03-05_budget", wantErr: true},
+		{name: "current segment is invalid", path: "./2021-03-05_budget", wantErr: true},
+		{name: "absolute path is invalid", path: "/tmp/2021-03-05_budget", wantErr: true},
+		{name: "empty segment is invalid", path: "work//2021-03-05_budget", wantErr: true},
+		{name: "empty path is invalid", path: "", wantErr: true},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := ValidatePath(tc.path); (err != nil) != tc.wantErr {
+				t.Errorf("got error %v, want error %t", err, tc.wantErr)
+			}
+		})
+	}
+}
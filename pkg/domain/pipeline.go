@@ -0,0 +1,144 @@
+package domain
+
+import (
+	"context"
+	"runtime"
+)
+
+// DefaultWorkers defines the number of concurrent workers used to parse notes by default
+var DefaultWorkers = runtime.NumCPU()
+
+// reorderWindow defines how many results per worker may be held while awaiting an earlier result
+const reorderWindow = 4
+
+// ParseResult represents the outcome of parsing a single Note from a source
+type ParseResult struct {
+	Source string // source that the note was parsed from
+	Note   Note   // parsed note, if successful
+	Err    error  // error encountered whilst parsing, if unsuccessful
+}
+
+// ParseFunc defines a function that parses a Note from the provided source
+type ParseFunc func(source string) (Note, error)
+
+// StreamNotes parses Notes from the provided sources using a bounded pool of workers,
+// streaming the results in the same order as the sources
+//
+// The returned channel is closed once all sources have been parsed or the provided context is done.
+func StreamNotes(ctx context.Context, sources []string, workers int, parse ParseFunc) <-chan ParseResult {
+	out := make(chan ParseResult)
+
+	results := runOrdered(ctx, len(sources), workers, func(idx int) interface{} {
+		n, err := parse(sources[idx])
+		return ParseResult{Source: sources[idx], Note: n, Err: err}
+	})
+
+	go func() {
+		defer close(out)
+		for r := range results {
+			select {
+			case out <- r.(ParseResult):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// orderedResult represents the result of a single job alongside its position
+type orderedResult struct {
+	idx int
+	val interface{}
+}
+
+// runOrdered invokes the provided job function for each index up to the provided total using a bounded pool of workers,
+// streaming the results in index order
+//
+// The number of results that are held while awaiting an earlier result is bounded, so memory use does not grow with total.
+func runOrdered(ctx context.Context, total, workers int, job func(idx int) interface{}) <-chan interface{} {
+	if workers < 1 {
+		workers = 1
+	}
+
+	out := make(chan interface{})
+	jobs := make(chan int)
+	results := make(chan orderedResult)
+	window := make(chan struct{}, workers*reorderWindow)
+
+	// dispatch jobs, blocking while too many results are awaiting an earlier result
+	go func() {
+		defer close(jobs)
+		for idx := 0; idx < total; idx++ {
+			select {
+			case window <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+
+			select {
+			case jobs <- idx:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	// process jobs
+	done := make(chan struct{})
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer func() {
+				done <- struct{}{}
+			}()
+
+			for idx := range jobs {
+				select {
+				case results <- orderedResult{idx: idx, val: job(idx)}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+		for w := 0; w < workers; w++ {
+			<-done
+		}
+		close(results)
+	}()
+
+	// emit results in order
+	go func() {
+		defer close(out)
+
+		pending := make(map[int]interface{})
+		next := 0
+
+		for r := range results {
+			pending[r.idx] = r.val
+
+			for {
+				val, ok := pending[next]
+				if !ok {
+					break
+				}
+
+				delete(pending, next)
+
+				select {
+				case out <- val:
+				case <-ctx.Done():
+					return
+				}
+
+				<-window
+				next++
+			}
+		}
+	}()
+
+	return out
+}
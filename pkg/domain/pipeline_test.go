@@ -0,0 +1,112 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+)
+
+// benchNotes defines the number of raw notes that are parsed by each benchmark iteration
+const benchNotes = 200
+
+// memFileSystem represents a FileSystem whose files are read from memory
+type memFileSystem struct {
+	FileSystem
+	files map[string][]byte
+}
+
+// ReadFile implements FileSystem
+func (m *memFileSystem) ReadFile(path string) ([]byte, error) {
+	b, ok := m.files[path]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+
+	return b, nil
+}
+
+// rawNoteSources returns an in-memory FileSystem containing the provided number of raw notes, along with their paths
+func rawNoteSources(total int) (*memFileSystem, []string) {
+	fs := &memFileSystem{files: make(map[string][]byte)}
+	sources := make([]string, total)
+
+	paragraph := strings.Repeat("Lorem ipsum dolor sit amet, <b>consectetur</b> adipiscing elit &amp; more. ", 20)
+
+	for idx := range sources {
+		sources[idx] = fmt.Sprintf("/export/Notes/%d/content.html", idx)
+		fs.files[sources[idx]] = []byte(fmt.Sprintf(
+			`<html><head><meta charset="utf-8"></head><body><div><a href="#">Back</a> Note %d</div><br><br>`+
+				`Create Time: 25/03/2021 10:00<br>Modify Time: 26/03/2021 11:30<br><br>`+
+				`<p>%s</p><p>Call +44 20 7946 0958 or visit https://example.com/%d</p>`+
+				`<ul><li>one</li><li>two</li></ul></body></html>`,
+			idx, paragraph, idx,
+		))
+	}
+
+	return fs, sources
+}
+
+func TestRunOrdered(t *testing.T) {
+	const total = 50
+
+	// earlier jobs take longer, so that later jobs finish first
+	job := func(idx int) interface{} {
+		time.Sleep(time.Duration(total-idx) * 100 * time.Microsecond)
+		return idx
+	}
+
+	for _, workers := range []int{1, 4, 16} {
+		var got []int
+		for val := range runOrdered(context.Background(), total, workers, job) {
+			got = append(got, val.(int))
+		}
+
+		if len(got) != total {
+			t.Fatalf("workers=%d: got %d results, want %d", workers, len(got), total)
+		}
+
+		for idx, val := range got {
+			if val != idx {
+				t.Fatalf("workers=%d: got result %d at position %d, want %d", workers, val, idx, idx)
+			}
+		}
+	}
+}
+
+func BenchmarkStreamNotes(b *testing.B) {
+	fs, sources := rawNoteSources(benchNotes)
+
+	tp, err := NewTimestampParser(time.UTC, []string{"2/1/2006 15:04"})
+	if err != nil {
+		b.Fatalf("unexpected error: %s", err)
+	}
+
+	counts := []int{1, 2, 4, 8}
+	if DefaultWorkers > counts[len(counts)-1] {
+		counts = append(counts, DefaultWorkers)
+	}
+
+	for _, workers := range counts {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			ns := NewNoteService(fs, WithWorkers(workers), WithTimestampParser(tp))
+
+			parse := func(source string) (Note, error) {
+				return ns.ParseFromRawFile(source, "Notes", path.Base(path.Dir(source)))
+			}
+
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				for r := range StreamNotes(context.Background(), sources, workers, parse) {
+					if r.Err != nil {
+						b.Fatalf("unexpected error: %s", r.Err)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync/atomic"
+
+	"gopkg.in/yaml.v2"
+)
+
+// RuleScope defines the fields of a Note that a Rule applies to
+type RuleScope string
+
+const (
+	// TitleScope applies a Rule to the title of a Note
+	TitleScope RuleScope = "title"
+	// ContentScope applies a Rule to the content of a Note
+	ContentScope RuleScope = "content"
+	// BothScope applies a Rule to both the title and the content of a Note
+	BothScope RuleScope = "both"
+)
+
+// Rule defines a user-defined sanitisation rule, which either finds a regular expression and replaces its matches,
+// or strips a literal string
+type Rule struct {
+	Name    string    `json:"name" yaml:"name"`       // name of the rule, used to report its matches
+	Find    string    `json:"find" yaml:"find"`       // regular expression to find
+	Replace string    `json:"replace" yaml:"replace"` // value to replace each match of find with, which may reference its groups
+	Strip   string    `json:"strip" yaml:"strip"`     // literal string to remove
+	Scope   RuleScope `json:"scope" yaml:"scope"`     // fields that the rule applies to, defaulting to both
+}
+
+// ruleFile represents the contents of a rules file
+type ruleFile struct {
+	Rules []Rule `json:"rules" yaml:"rules"`
+}
+
+// RuleCount represents the number of matches of a single Rule
+type RuleCount struct {
+	Name    string
+	Matches int64
+}
+
+// RuleSet represents an ordered set of validated Rules, along with the number of matches of each
+type RuleSet struct {
+	rules  []findReplaceRule
+	scopes []RuleScope
+	names  []string
+	counts []int64
+}
+
+// ParseRuleSet parses a RuleSet from the provided rules file contents, which are either yaml or json
+func ParseRuleSet(b []byte, isYAML bool) (*RuleSet, error) {
+	var f ruleFile
+
+	if isYAML {
+		if err := yaml.UnmarshalStrict(b, &f); err != nil {
+			return nil, fmt.Errorf("cannot yaml decode rules: %w", err)
+		}
+	} else {
+		dec := json.NewDecoder(strings.NewReader(string(b)))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&f); err != nil {
+			return nil, fmt.Errorf("cannot json decode rules: %w", err)
+		}
+	}
+
+	rs := &RuleSet{}
+
+	for idx, r := range f.Rules {
+		compiled, err := r.compile()
+		if err != nil {
+			return nil, fmt.Errorf("invalid rule %d: %w", idx+1, err)
+		}
+
+		if r.Name == "" {
+			r.Name = fmt.Sprintf("rule %d", idx+1)
+		}
+
+		if r.Scope == "" {
+			r.Scope = BothScope
+		}
+
+		rs.rules = append(rs.rules, compiled)
+		rs.scopes = append(rs.scopes, r.Scope)
+		rs.names = append(rs.names, r.Name)
+	}
+
+	rs.counts = make([]int64, len(rs.rules))
+
+	return rs, nil
+}
+
+// compile validates the Rule and returns the find/replace rule that it represents
+func (r Rule) compile() (findReplaceRule, error) {
+	switch r.Scope {
+	case "", TitleScope, ContentScope, BothScope:
+	default:
+		return findReplaceRule{}, fmt.Errorf("invalid scope: %s", r.Scope)
+	}
+
+	switch {
+	case r.Find != "" && r.Strip != "":
+		return findReplaceRule{}, errors.New("must provide either find or strip, not both")
+	case r.Strip != "":
+		if r.Replace != "" {
+			return findReplaceRule{}, errors.New("cannot replace a stripped string")
+		}
+		return findReplaceRule{find: regexp.MustCompile(regexp.QuoteMeta(r.Strip))}, nil
+	case r.Find != "":
+		find, err := regexp.Compile(r.Find)
+		if err != nil {
+			return findReplaceRule{}, fmt.Errorf("invalid find pattern: %w", err)
+		}
+		return findReplaceRule{find: find, replace: r.Replace}, nil
+	}
+
+	return findReplaceRule{}, errors.New("must provide either find or strip")
+}
+
+// Len returns the number of rules within the RuleSet
+func (rs *RuleSet) Len() int {
+	if rs == nil {
+		return 0
+	}
+	return len(rs.rules)
+}
+
+// Counts returns the number of matches of each rule within the RuleSet so far, in order
+func (rs *RuleSet) Counts() []RuleCount {
+	var counts []RuleCount
+
+	for idx := 0; idx < rs.Len(); idx++ {
+		counts = append(counts, RuleCount{Name: rs.names[idx], Matches: atomic.LoadInt64(&rs.counts[idx])})
+	}
+
+	return counts
+}
+
+// apply applies each rule within the RuleSet that applies to the provided scope to the provided input in turn,
+// counting their matches if count is true
+func (rs *RuleSet) apply(scope RuleScope, inp string, count bool) string {
+	for idx := 0; idx < rs.Len(); idx++ {
+		if rs.scopes[idx] != BothScope && rs.scopes[idx] != scope {
+			continue
+		}
+
+		r := rs.rules[idx]
+
+		matches := r.find.FindAllStringIndex(inp, -1)
+		if len(matches) == 0 {
+			continue
+		}
+
+		if count {
+			atomic.AddInt64(&rs.counts[idx], int64(len(matches)))
+		}
+		inp = r.find.ReplaceAllString(inp, r.replace)
+	}
+
+	return inp
+}
@@ -0,0 +1,141 @@
+package domain
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ruleSetYAML and ruleSetJSON define the same rules as yaml and json
+const (
+	ruleSetYAML = `
+rules:
+  - name: spelling
+    find: colou?r
+    replace: hue
+  - name: signature
+    strip: "-- sent from my phone"
+    scope: content
+  - find: '^DRAFT:\s*'
+    scope: title
+`
+	ruleSetJSON = `{"rules": [
+  {"name": "spelling", "find": "colou?r", "replace": "hue"},
+  {"name": "signature", "strip": "-- sent from my phone", "scope": "content"},
+  {"find": "^DRAFT:\\s*", "scope": "title"}
+]}`
+)
+
+func TestParseRuleSet(t *testing.T) {
+	for _, format := range []struct {
+		name   string
+		inp    string
+		isYAML bool
+	}{
+		{name: "yaml", inp: ruleSetYAML, isYAML: true},
+		{name: "json", inp: ruleSetJSON},
+	} {
+		rs, err := ParseRuleSet([]byte(format.inp), format.isYAML)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", format.name, err)
+		}
+
+		if got := rs.apply(TitleScope, "DRAFT: Colour and colour", true); got != "Colour and hue" {
+			t.Errorf("%s: got title %q, want %q", format.name, got, "Colour and hue")
+		}
+
+		if got := rs.apply(ContentScope, "DRAFT: the color\n-- sent from my phone", true); got != "DRAFT: the hue\n" {
+			t.Errorf("%s: got content %q, want %q", format.name, got, "DRAFT: the hue\n")
+		}
+
+		want := []RuleCount{{Name: "spelling", Matches: 2}, {Name: "signature", Matches: 1}, {Name: "rule 3", Matches: 1}}
+		if got := rs.Counts(); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got counts %+v, want %+v", format.name, got, want)
+		}
+	}
+}
+
+func TestParseRuleSet_Invalid(t *testing.T) {
+	tt := []struct {
+		name string
+		inp  string
+		want string
+	}{
+		{
+			name: "invalid regular expression",
+			inp:  `{"rules": [{"strip": "x"}, {"find": "(unclosed"}]}`,
+			want: "invalid rule 2: invalid find pattern",
+		},
+		{
+			name: "find and strip",
+			inp:  `{"rules": [{"find": "x", "strip": "y"}]}`,
+			want: "invalid rule 1: must provide either find or strip, not both",
+		},
+		{
+			name: "replaced strip",
+			inp:  `{"rules": [{"strip": "x", "replace": "y"}]}`,
+			want: "invalid rule 1: cannot replace a stripped string",
+		},
+		{
+			name: "invalid scope",
+			inp:  `{"rules": [{"strip": "x", "scope": "body"}]}`,
+			want: "invalid rule 1: invalid scope: body",
+		},
+		{
+			name: "unknown field",
+			inp:  `{"rules": [{"srip": "x"}]}`,
+			want: "cannot json decode rules",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseRuleSet([]byte(tc.inp), false)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("got error %v, want %q", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestRuleSet_Order(t *testing.T) {
+	rs, err := ParseRuleSet([]byte(`{"rules": [{"find": "cat", "replace": "dog"}, {"find": "dog", "replace": "wolf"}]}`), false)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// later rules apply to the output of earlier rules
+	if got := rs.apply(ContentScope, "cat", true); got != "wolf" {
+		t.Errorf("got %q, want %q", got, "wolf")
+	}
+}
+
+func TestNoteService_RuleCounts(t *testing.T) {
+	rs, err := ParseRuleSet([]byte(ruleSetJSON), false)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	ns := NewNoteService(nil, WithRuleSet(rs))
+
+	_, err = ns.ParseFromExportedNote(ExportedNote{
+		ID:         "101",
+		Title:      "DRAFT: colours",
+		Body:       "<p>Pick a colour</p><p><input type=\"checkbox\"> buy colour chart</p>",
+		HTML:       true,
+		CreatedAt:  time.Date(2021, 3, 25, 10, 0, 0, 0, time.UTC),
+		ModifiedAt: time.Date(2021, 3, 25, 10, 0, 0, 0, time.UTC),
+		Checklist:  []ChecklistItem{{Text: "mix a color"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// the title, content and detached checklist item each match once, while the item rendered in place
+	// is only counted within the content
+	want := []RuleCount{{Name: "spelling", Matches: 4}, {Name: "signature", Matches: 0}, {Name: "rule 3", Matches: 1}}
+	if got := rs.Counts(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got counts %+v, want %+v", got, want)
+	}
+}
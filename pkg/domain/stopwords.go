@@ -0,0 +1,123 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// DefaultStopwords defines the words of each language that are too common to be keywords, keyed by language code
+var DefaultStopwords = map[string][]string{
+	"en": {
+		"a", "about", "after", "again", "all", "also", "am", "an", "and", "any", "are", "as", "at", "be", "because",
+		"been", "before", "being", "but", "by", "can", "could", "did", "do", "does", "done", "for", "from", "get",
+		"got", "had", "has", "have", "he", "her", "here", "him", "his", "how", "i", "if", "in", "into", "is", "it",
+		"its", "just", "like", "me", "more", "my", "need", "no", "not", "now", "of", "off", "on", "one", "only", "or",
+		"our", "out", "over", "she", "should", "so", "some", "than", "that", "the", "their", "them", "then", "there",
+		"these", "they", "this", "those", "to", "too", "up", "us", "very", "was", "we", "were", "what", "when",
+		"where", "which", "who", "why", "will", "with", "would", "you", "your",
+	},
+	"de": {
+		"aber", "alle", "als", "am", "an", "auch", "auf", "aus", "bei", "bin", "bis", "das", "dass", "dem", "den",
+		"der", "des", "die", "doch", "du", "ein", "eine", "einem", "einen", "einer", "er", "es", "für", "hat",
+		"haben", "ich", "ihr", "im", "in", "ist", "ja", "kann", "mit", "nach", "nicht", "noch", "nur", "oder",
+		"sich", "sie", "sind", "so", "um", "und", "uns", "von", "vor", "war", "was", "wie", "wir", "wird", "zu",
+		"zum", "zur",
+	},
+	"es": {
+		"a", "al", "como", "con", "de", "del", "el", "en", "es", "esta", "este", "esto", "ha", "la", "las", "le",
+		"lo", "los", "más", "me", "mi", "muy", "no", "o", "para", "pero", "por", "que", "se", "si", "sin", "sobre",
+		"su", "sus", "también", "te", "un", "una", "uno", "y", "ya", "yo",
+	},
+	"fr": {
+		"à", "au", "aux", "avec", "ce", "ces", "dans", "de", "des", "du", "elle", "en", "est", "et", "il", "je",
+		"la", "le", "les", "leur", "lui", "ma", "mais", "me", "mes", "mon", "ne", "nous", "on", "ou", "par", "pas",
+		"pour", "qu", "que", "qui", "sa", "se", "ses", "son", "sur", "ta", "te", "tu", "un", "une", "vous",
+	},
+	"it": {
+		"a", "al", "alla", "che", "ci", "come", "con", "da", "del", "della", "di", "e", "è", "gli", "ha", "i",
+		"il", "in", "la", "le", "lo", "ma", "mi", "non", "per", "più", "se", "si", "sono", "su", "tra", "un",
+		"una", "uno",
+	},
+	"pt": {
+		"a", "ao", "as", "com", "como", "da", "das", "de", "do", "dos", "e", "é", "ela", "ele", "em", "eu", "isso",
+		"mais", "mas", "me", "na", "não", "nas", "no", "nos", "o", "os", "ou", "para", "pela", "pelo", "por",
+		"que", "se", "sem", "seu", "sua", "também", "um", "uma",
+	},
+	"nl": {
+		"aan", "al", "als", "bij", "dat", "de", "die", "dit", "een", "en", "er", "het", "hij", "ik", "in", "is",
+		"je", "maar", "met", "naar", "niet", "nog", "of", "om", "ook", "op", "over", "te", "tot", "uit", "van",
+		"voor", "was", "wat", "we", "wel", "zijn", "ze",
+	},
+}
+
+// StopwordLists represents the stopwords of each language, keyed by language code
+type StopwordLists map[string]map[string]bool
+
+// NewStopwordLists returns the StopwordLists that represent the provided words of each language
+func NewStopwordLists(words map[string][]string) StopwordLists {
+	lists := make(StopwordLists)
+
+	for lang, list := range words {
+		lists[lang] = make(map[string]bool)
+		for _, w := range list {
+			lists[lang][strings.ToLower(strings.TrimSpace(w))] = true
+		}
+	}
+
+	return lists
+}
+
+// ParseStopwords parses the stopwords of each language from the provided json-encoded object of lists
+func ParseStopwords(b []byte) (map[string][]string, error) {
+	var words map[string][]string
+	if err := json.Unmarshal(b, &words); err != nil {
+		return nil, fmt.Errorf("cannot json decode stopwords: %w", err)
+	}
+
+	for lang, list := range words {
+		switch {
+		case strings.TrimSpace(lang) == "":
+			return nil, errors.New("stopwords language is empty")
+		case len(list) == 0:
+			return nil, fmt.Errorf("stopwords for language %s are empty", lang)
+		}
+	}
+
+	return words, nil
+}
+
+// contains returns true if the provided word is a stopword of any language
+func (sl StopwordLists) contains(w string) bool {
+	for _, list := range sl {
+		if list[w] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// detectLanguage returns the language whose stopwords occur most often within the provided words,
+// or an empty string if none occur
+func (sl StopwordLists) detectLanguage(words []string) string {
+	var best string
+	var bestCount int
+
+	for lang, list := range sl {
+		var count int
+		for _, w := range words {
+			if list[w] {
+				count++
+			}
+		}
+
+		// break ties by language code so that detection is deterministic
+		if count > bestCount || (count == bestCount && count > 0 && lang < best) {
+			best, bestCount = lang, count
+		}
+	}
+
+	return best
+}
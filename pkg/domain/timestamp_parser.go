@@ -0,0 +1,160 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	_ "time/tzdata" // ensure timezones can be loaded regardless of host
+)
+
+// DefaultLocation defines the name of the timezone that raw timestamps are parsed in by default
+const DefaultLocation = "Europe/London"
+
+// DefaultTimestampLayouts defines the raw timestamp layouts that are attempted by default, in order of preference
+var DefaultTimestampLayouts = []string{
+	"2/1/2006 15:04",   // DD/MM/YYYY 24-hour
+	"1/2/2006 15:04",   // MM/DD/YYYY 24-hour
+	"2/1/2006 3:04 PM", // DD/MM/YYYY 12-hour
+	"1/2/2006 3:04 PM", // MM/DD/YYYY 12-hour
+	"2.1.2006 15:04",   // DD.MM.YYYY 24-hour, as written in german and russian locales
+	"2006/1/2 15:04",   // YYYY/MM/DD 24-hour, as written in chinese and japanese locales
+	"2006-1-2 15:04",   // YYYY-MM-DD 24-hour
+}
+
+// ErrInvalidTimestamp is returned when a raw timestamp cannot be parsed using any candidate layout
+var ErrInvalidTimestamp = errors.New("invalid timestamp")
+
+// TimestampParser parses raw timestamps using one of a number of candidate layouts
+type TimestampParser struct {
+	loc     *time.Location
+	layouts []string
+	layout  string // layout detected from a full set of raw timestamps
+}
+
+// Layout returns the layout that has been detected, or an empty string if no detection has occurred
+func (t *TimestampParser) Layout() string {
+	return t.layout
+}
+
+// Detect determines the single candidate layout that consistently parses all of the provided raw timestamps
+//
+// Returns an error if no candidate layout parses every timestamp, or if more than one candidate layout
+// parses every timestamp but produces different results (i.e. the day/month order is ambiguous).
+func (t *TimestampParser) Detect(raws []string) error {
+	if len(raws) == 0 {
+		return errors.New("no timestamps to detect layout from")
+	}
+
+	var candidates []string
+	var parsed [][]time.Time
+
+	for _, layout := range t.layouts {
+		times, err := t.parseAll(layout, raws)
+		if err != nil {
+			continue
+		}
+
+		candidates = append(candidates, layout)
+		parsed = append(parsed, times)
+	}
+
+	if len(candidates) == 0 {
+		return fmt.Errorf("no layout in %q parses every timestamp", t.layouts)
+	}
+
+	// more than one candidate is only acceptable if each of them agrees on every timestamp
+	for i := 1; i < len(candidates); i++ {
+		for j := range raws {
+			if !parsed[i][j].Equal(parsed[0][j]) {
+				return fmt.Errorf(
+					"ambiguous timestamp layouts %q and %q both parse every timestamp (e.g. %s), specify a single layout",
+					candidates[0],
+					candidates[i],
+					raws[j],
+				)
+			}
+		}
+	}
+
+	t.layout = candidates[0]
+
+	return nil
+}
+
+// Parse parses the provided raw timestamp using the detected layout,
+// otherwise using the first candidate layout that succeeds
+func (t *TimestampParser) Parse(raw string) (time.Time, error) {
+	raw = strings.Trim(raw, " \n")
+
+	if t.layout != "" {
+		ts, err := time.ParseInLocation(t.layout, raw, t.loc)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%w: %s", ErrInvalidTimestamp, err)
+		}
+		return ts, nil
+	}
+
+	for _, layout := range t.layouts {
+		if ts, err := time.ParseInLocation(layout, raw, t.loc); err == nil {
+			return ts, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("%w: %s does not match any layout in %q", ErrInvalidTimestamp, raw, t.layouts)
+}
+
+// ParseWithLayouts parses the provided raw timestamp in the parser's location, using the candidate layouts
+// followed by the provided layouts, regardless of any detected layout
+func (t *TimestampParser) ParseWithLayouts(raw string, layouts ...string) (time.Time, error) {
+	raw = strings.Trim(raw, " \n")
+	candidates := append(append([]string(nil), t.layouts...), layouts...)
+
+	for _, layout := range candidates {
+		if ts, err := time.ParseInLocation(layout, raw, t.loc); err == nil {
+			return ts, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("%w: %s does not match any layout in %q", ErrInvalidTimestamp, raw, candidates)
+}
+
+// parseAll parses all of the provided raw timestamps using the provided layout
+func (t *TimestampParser) parseAll(layout string, raws []string) ([]time.Time, error) {
+	var times []time.Time
+
+	for _, raw := range raws {
+		ts, err := time.ParseInLocation(layout, strings.Trim(raw, " \n"), t.loc)
+		if err != nil {
+			return nil, err
+		}
+
+		times = append(times, ts)
+	}
+
+	return times, nil
+}
+
+// NewTimestampParser returns a new TimestampParser that parses in the provided location using the provided candidate layouts
+func NewTimestampParser(loc *time.Location, layouts []string) (*TimestampParser, error) {
+	if loc == nil {
+		return nil, errors.New("must provide a location")
+	}
+
+	if len(layouts) == 0 {
+		return nil, errors.New("must provide at least one layout")
+	}
+
+	return &TimestampParser{loc: loc, layouts: layouts}, nil
+}
+
+// defaultTimestampParser returns a TimestampParser using the default location and layouts
+func defaultTimestampParser() *TimestampParser {
+	loc, err := time.LoadLocation(DefaultLocation)
+	if err != nil {
+		// timezone database is embedded so this should never happen
+		loc = time.UTC
+	}
+
+	return &TimestampParser{loc: loc, layouts: DefaultTimestampLayouts}
+}
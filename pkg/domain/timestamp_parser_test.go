@@ -0,0 +1,121 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimestampParser_Detect(t *testing.T) {
+	tt := []struct {
+		name    string
+		raws    []string
+		want    string
+		wantErr string
+	}{
+		{
+			name: "day first is detected from a day beyond 12",
+			raws: []string{"01/04/2021 09:00", "25/03/2021 10:00"},
+			want: "2/1/2006 15:04",
+		},
+		{
+			name: "month first is detected from a day beyond 12",
+			raws: []string{"04/01/2021 09:00", "03/25/2021 10:00"},
+			want: "1/2/2006 15:04",
+		},
+		{
+			name: "layouts that agree on every timestamp are not ambiguous",
+			raws: []string{"05/05/2021 10:00", "12/12/2021 23:59"},
+			want: "2/1/2006 15:04",
+		},
+		{
+			name: "12-hour clock is detected",
+			raws: []string{"25/03/2021 3:04 PM", "26/03/2021 11:30 AM"},
+			want: "2/1/2006 3:04 PM",
+		},
+		{
+			name: "dotted day first is detected",
+			raws: []string{"25.03.2021 10:00"},
+			want: "2.1.2006 15:04",
+		},
+		{
+			name: "year first is detected",
+			raws: []string{"2021/03/25 10:00", "2021/4/1 9:00"},
+			want: "2006/1/2 15:04",
+		},
+		{
+			name:    "day and month order cannot be determined",
+			raws:    []string{"01/04/2021 09:00", "02/03/2021 10:00"},
+			wantErr: "ambiguous timestamp layouts",
+		},
+		{
+			name:    "timestamps in different layouts are not parsed by any single layout",
+			raws:    []string{"25/03/2021 10:00", "2021/03/25 10:00"},
+			wantErr: "no layout",
+		},
+		{
+			name:    "no timestamps cannot be detected",
+			wantErr: "no timestamps",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			tp, err := NewTimestampParser(time.UTC, DefaultTimestampLayouts)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			err = tp.Detect(tc.raws)
+
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Errorf("got error %v, want error containing %q", err, tc.wantErr)
+				}
+				if tp.Layout() != "" {
+					t.Errorf("got layout %q, want none", tp.Layout())
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if got := tp.Layout(); got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestTimestampParser_Parse(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	tp, err := NewTimestampParser(loc, DefaultTimestampLayouts)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if err := tp.Detect([]string{"03/25/2021 10:00"}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// the detected layout is used in place of the first candidate that succeeds
+	got, err := tp.Parse(" 04/01/2021 09:00\n")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if want := time.Date(2021, 4, 1, 9, 0, 0, 0, loc); !got.Equal(want) {
+		t.Errorf("got %s, want %s", got, want)
+	}
+
+	if _, err := tp.Parse("25/03/2021 10:00"); !errors.Is(err, ErrInvalidTimestamp) {
+		t.Errorf("got error %v, want %v", err, ErrInvalidTimestamp)
+	}
+}